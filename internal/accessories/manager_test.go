@@ -1,6 +1,7 @@
 package accessories
 
 import (
+	"context"
 	"errors"
 	"io"
 	"strings"
@@ -54,6 +55,22 @@ func (m *AccessoriesMockExecutor) RunStream(cmd string, stdout, stderr io.Writer
 	return nil
 }
 
+func (m *AccessoriesMockExecutor) RunContext(ctx context.Context, cmd string) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
+func (m *AccessoriesMockExecutor) RunInteractiveContext(ctx context.Context, cmd string) error {
+	return m.RunInteractive(cmd)
+}
+
+func (m *AccessoriesMockExecutor) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	return m.RunStream(cmd, stdout, stderr)
+}
+
+func (m *AccessoriesMockExecutor) RunWithStdin(cmd string, stdin io.Reader) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
 func (m *AccessoriesMockExecutor) WriteFile(path string, content []byte) error {
 	return nil
 }
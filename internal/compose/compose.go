@@ -0,0 +1,194 @@
+package compose
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Project represents a parsed docker-compose file
+type Project struct {
+	Version  string               `yaml:"version,omitempty"`
+	Name     string               `yaml:"name,omitempty"`
+	Services map[string]*Service  `yaml:"services,omitempty"`
+	Networks map[string]*Resource `yaml:"networks,omitempty"`
+	Volumes  map[string]*Resource `yaml:"volumes,omitempty"`
+	Secrets  map[string]*Resource `yaml:"secrets,omitempty"`
+	Configs  map[string]*Resource `yaml:"configs,omitempty"`
+
+	// Extensions holds top-level keys not modelled above (e.g. x-* blocks)
+	Extensions map[string]interface{} `yaml:",inline"`
+}
+
+// Service represents a single service definition
+type Service struct {
+	Image     string          `yaml:"image,omitempty"`
+	DependsOn DependsOn       `yaml:"depends_on,omitempty"`
+	Networks  ServiceNetworks `yaml:"networks,omitempty"`
+	Secrets   []FileReference `yaml:"secrets,omitempty"`
+	Configs   []FileReference `yaml:"configs,omitempty"`
+	EnvFile   EnvFiles        `yaml:"env_file,omitempty"`
+
+	// Extra holds every other service key, preserved as-is
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+// Resource represents a top-level network, volume, secret or config definition
+type Resource struct {
+	Name        string `yaml:"name,omitempty"`
+	External    bool   `yaml:"external,omitempty"`
+	File        string `yaml:"file,omitempty"`
+	Environment string `yaml:"environment,omitempty"`
+
+	// Extra holds every other key (driver, labels, ...), preserved as-is
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+// Parse parses compose file content into a Project
+func Parse(data []byte) (*Project, error) {
+	p := &Project{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	p.normalize()
+	return p, nil
+}
+
+// Load reads and parses a compose file from disk
+func Load(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Marshal renders the project back to YAML
+func (p *Project) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(p); err != nil {
+		return nil, fmt.Errorf("failed to render compose file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to render compose file: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ServiceNames returns the names of all services, sorted
+func (p *Project) ServiceNames() []string {
+	return sortedKeys(p.Services)
+}
+
+// Images returns a map of service name to image
+func (p *Project) Images() map[string]string {
+	images := make(map[string]string)
+	for name, svc := range p.Services {
+		if svc.Image != "" {
+			images[name] = svc.Image
+		}
+	}
+	return images
+}
+
+// KeepServices removes every service not listed in names.
+// Returns an error if a listed service does not exist.
+func (p *Project) KeepServices(names ...string) error {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := p.Services[name]; !ok {
+			return fmt.Errorf("service %s not found in compose file", name)
+		}
+		keep[name] = true
+	}
+
+	var drop []string
+	for name := range p.Services {
+		if !keep[name] {
+			drop = append(drop, name)
+		}
+	}
+	p.RemoveServices(drop...)
+	return nil
+}
+
+// RemoveServices removes the named services and any depends_on
+// entries pointing at them. Top-level networks, volumes, secrets and
+// configs are left untouched so remaining services keep their references.
+// Names that don't exist are ignored.
+func (p *Project) RemoveServices(names ...string) {
+	for _, name := range names {
+		delete(p.Services, name)
+	}
+	for _, svc := range p.Services {
+		for _, name := range names {
+			delete(svc.DependsOn, name)
+		}
+	}
+}
+
+// normalize replaces null entries with empty definitions so callers
+// never have to nil-check map values
+func (p *Project) normalize() {
+	for name, svc := range p.Services {
+		if svc == nil {
+			p.Services[name] = &Service{}
+		}
+	}
+	for _, resources := range []map[string]*Resource{p.Networks, p.Volumes, p.Secrets, p.Configs} {
+		for name, r := range resources {
+			if r == nil {
+				resources[name] = &Resource{}
+			}
+		}
+	}
+}
+
+// UnmarshalYAML accepts the legacy `external: {name: foo}` form
+func (r *Resource) UnmarshalYAML(value *yaml.Node) error {
+	type plain Resource
+
+	if value.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key, val := value.Content[i], value.Content[i+1]
+			if key.Value != "external" || val.Kind != yaml.MappingNode {
+				continue
+			}
+
+			var legacy struct {
+				Name string `yaml:"name"`
+			}
+			if err := val.Decode(&legacy); err != nil {
+				return err
+			}
+
+			// Rewrite to the current form on a copy of the node
+			node := *value
+			node.Content = append([]*yaml.Node{}, value.Content...)
+			node.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"}
+			if err := node.Decode((*plain)(r)); err != nil {
+				return err
+			}
+			if r.Name == "" {
+				r.Name = legacy.Name
+			}
+			return nil
+		}
+	}
+
+	return value.Decode((*plain)(r))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,261 @@
+package compose
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleCompose = `version: "3.8"
+
+x-defaults: &defaults
+  restart: unless-stopped
+  networks: [backend]
+
+services:
+  web:
+    <<: *defaults
+    image: ghcr.io/myuser/myapp:latest # trailing comment
+    depends_on:
+      - redis
+      - postgres
+    environment: {RAILS_ENV: production, "KEY:": value}
+    ports:
+      - "80:3000"
+    secrets:
+      - myapp_database_url
+      - source: myapp_api_key
+        target: api_key
+    env_file: .env.web
+
+  redis:
+    image: redis:7-alpine
+
+  postgres:
+    image: postgres:16
+    depends_on:
+      redis:
+        condition: service_started
+    volumes:
+      - pg_data:/var/lib/postgresql/data
+
+networks:
+  backend:
+
+volumes:
+  pg_data:
+
+secrets:
+  myapp_database_url:
+    external: true
+  myapp_api_key:
+    external:
+      name: legacy_api_key
+
+configs:
+  nginx_conf:
+    file: ./nginx.conf
+`
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(sampleCompose))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if got := p.ServiceNames(); strings.Join(got, ",") != "postgres,redis,web" {
+		t.Errorf("ServiceNames() = %v", got)
+	}
+
+	web := p.Services["web"]
+	if web.Image != "ghcr.io/myuser/myapp:latest" {
+		t.Errorf("web image = %q", web.Image)
+	}
+
+	// Anchor merge should bring in networks and restart
+	if _, ok := web.Networks["backend"]; !ok {
+		t.Errorf("web networks = %v, want backend via anchor", web.Networks)
+	}
+	if web.Extra["restart"] != "unless-stopped" {
+		t.Errorf("web restart = %v, want unless-stopped via anchor", web.Extra["restart"])
+	}
+
+	if len(web.DependsOn) != 2 {
+		t.Errorf("web depends_on = %v, want 2 entries", web.DependsOn)
+	}
+
+	if len(web.Secrets) != 2 || web.Secrets[0].Source != "myapp_database_url" || web.Secrets[1].Target != "api_key" {
+		t.Errorf("web secrets = %+v", web.Secrets)
+	}
+
+	if len(web.EnvFile) != 1 || web.EnvFile[0].Path != ".env.web" {
+		t.Errorf("web env_file = %+v", web.EnvFile)
+	}
+
+	if p.Services["postgres"].DependsOn["redis"].Condition != "service_started" {
+		t.Errorf("postgres depends_on = %+v", p.Services["postgres"].DependsOn)
+	}
+
+	if _, ok := p.Volumes["pg_data"]; !ok || p.Volumes["pg_data"] == nil {
+		t.Error("expected non-nil pg_data volume")
+	}
+
+	if !p.Secrets["myapp_api_key"].External || p.Secrets["myapp_api_key"].Name != "legacy_api_key" {
+		t.Errorf("legacy external secret = %+v", p.Secrets["myapp_api_key"])
+	}
+
+	if p.Configs["nginx_conf"].File != "./nginx.conf" {
+		t.Errorf("config file = %q", p.Configs["nginx_conf"].File)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse([]byte("services: [unclosed")); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+
+	if _, err := Parse([]byte("services:\n  web:\n    depends_on: redis\n")); err == nil {
+		t.Error("expected error for scalar depends_on")
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	p, err := Parse([]byte(""))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(p.Services) != 0 {
+		t.Errorf("expected no services, got %d", len(p.Services))
+	}
+}
+
+func TestImages(t *testing.T) {
+	p, err := Parse([]byte(sampleCompose))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := p.Images()
+	expected := map[string]string{
+		"web":      "ghcr.io/myuser/myapp:latest",
+		"redis":    "redis:7-alpine",
+		"postgres": "postgres:16",
+	}
+
+	if len(images) != len(expected) {
+		t.Errorf("Images() = %v", images)
+	}
+	for svc, image := range expected {
+		if images[svc] != image {
+			t.Errorf("image for %s = %q, want %q", svc, images[svc], image)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	p, err := Parse([]byte(sampleCompose))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := p.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	// Short syntaxes should be preserved
+	for _, want := range []string{
+		"- myapp_database_url",
+		"source: myapp_api_key",
+		"env_file:",
+		"- .env.web",
+		"condition: service_started",
+		"80:3000",
+		"x-defaults:",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("rendered compose missing %q:\n%s", want, out)
+		}
+	}
+
+	again, err := Parse(out)
+	if err != nil {
+		t.Fatalf("re-Parse() error = %v\n%s", err, out)
+	}
+
+	if len(again.Services) != 3 || again.Services["web"].Image != p.Services["web"].Image {
+		t.Errorf("round trip lost services: %+v", again.Services)
+	}
+	if again.Services["web"].Extra["environment"].(map[string]interface{})["KEY:"] != "value" {
+		t.Errorf("round trip lost environment: %+v", again.Services["web"].Extra["environment"])
+	}
+	if again.Secrets["myapp_api_key"].Name != "legacy_api_key" {
+		t.Errorf("round trip lost legacy external name")
+	}
+}
+
+func TestKeepServices(t *testing.T) {
+	p, err := Parse([]byte(sampleCompose))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.KeepServices("postgres"); err != nil {
+		t.Fatalf("KeepServices() error = %v", err)
+	}
+
+	if got := p.ServiceNames(); len(got) != 1 || got[0] != "postgres" {
+		t.Errorf("ServiceNames() = %v, want [postgres]", got)
+	}
+
+	if len(p.Services["postgres"].DependsOn) != 0 {
+		t.Errorf("dangling depends_on should be removed: %+v", p.Services["postgres"].DependsOn)
+	}
+
+	// Top-level definitions are kept
+	if _, ok := p.Volumes["pg_data"]; !ok {
+		t.Error("pg_data volume should be kept")
+	}
+	if _, ok := p.Networks["backend"]; !ok {
+		t.Error("backend network should be kept")
+	}
+}
+
+func TestKeepServices_Unknown(t *testing.T) {
+	p, err := Parse([]byte(sampleCompose))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.KeepServices("nonexistent"); err == nil {
+		t.Error("expected error for unknown service")
+	}
+
+	if len(p.Services) != 3 {
+		t.Errorf("services should be untouched on error, got %d", len(p.Services))
+	}
+}
+
+func TestRemoveServices(t *testing.T) {
+	p, err := Parse([]byte(sampleCompose))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.RemoveServices("redis", "postgres", "nonexistent")
+
+	if got := p.ServiceNames(); len(got) != 1 || got[0] != "web" {
+		t.Errorf("ServiceNames() = %v, want [web]", got)
+	}
+
+	if len(p.Services["web"].DependsOn) != 0 {
+		t.Errorf("depends_on should be empty, got %+v", p.Services["web"].DependsOn)
+	}
+
+	out, err := p.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "depends_on") {
+		t.Errorf("empty depends_on should be omitted:\n%s", out)
+	}
+}
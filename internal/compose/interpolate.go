@@ -0,0 +1,98 @@
+package compose
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/marcelsud/swarmctl/internal/interpolate"
+	"gopkg.in/yaml.v3"
+)
+
+// Interpolate substitutes variables in every value of a compose document
+// following docker compose rules ($VAR, ${VAR:-default}, ${VAR?err}, $$).
+// Keys are left untouched. Every $ left in an expanded value is written
+// back as $$, as docker compose config does, so docker stack deploy and
+// docker compose don't interpolate the rendered file a second time. It
+// also returns the sorted names of variables that were referenced without
+// a default but are not set.
+func Interpolate(data []byte, lookup interpolate.LookupFunc) ([]byte, []string, error) {
+	if !bytes.Contains(data, []byte("$")) {
+		return data, nil, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+
+	e := &interpolate.Expander{Lookup: lookup}
+	var errs []error
+	interpolateNode(&doc, "", e, &errs)
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("failed to interpolate compose file: %w", errors.Join(errs...))
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to render compose file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to render compose file: %w", err)
+	}
+
+	unset := e.Unset
+	sort.Strings(unset)
+	return buf.Bytes(), unset, nil
+}
+
+// interpolateNode expands every scalar value under node. Aliases are not
+// followed, so anchored values are only expanded once.
+func interpolateNode(node *yaml.Node, path string, e *interpolate.Expander, errs *[]error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			interpolateNode(child, path, e, errs)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			interpolateNode(node.Content[i+1], key, e, errs)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			interpolateNode(item, path+"["+strconv.Itoa(i)+"]", e, errs)
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return
+		}
+		value, err := e.Expand(node.Value)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+			return
+		}
+		node.Value = strings.ReplaceAll(value, "$", "$$")
+		// Numbers from plain scalars stay numbers, so ${REPLICAS} can fill
+		// an int field; anything else (true, null, ...) stays a string
+		if node.Style == 0 && isNumber(value) {
+			node.Tag = ""
+		}
+	}
+}
+
+func isNumber(s string) bool {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -0,0 +1,98 @@
+package compose
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]string{
+		"IMAGE_TAG": "v1.2.3",
+		"REPLICAS":  "3",
+		"DEBUG":     "true",
+		"DB_HASH":   "$2y$10$abc",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+
+	data := []byte(`services:
+  web:
+    image: myapp:${IMAGE_TAG}
+    environment:
+      DEBUG: ${DEBUG}
+      REGION: ${REGION:-us-east-1}
+      SENTRY_DSN: $SENTRY_DSN
+      PRICE: "$$5"
+      HOME_DIR: $$HOME
+      DB_HASH: ${DB_HASH}
+    deploy:
+      replicas: ${REPLICAS}
+`)
+
+	out, unset, err := Interpolate(data, lookup)
+	if err != nil {
+		t.Fatalf("Interpolate failed: %v", err)
+	}
+
+	p, err := Parse(out)
+	if err != nil {
+		t.Fatalf("rendered compose is invalid: %v\n%s", err, out)
+	}
+	web := p.Services["web"]
+	if web.Image != "myapp:v1.2.3" {
+		t.Errorf("expected interpolated image, got %s", web.Image)
+	}
+
+	env := web.Extra["environment"].(map[string]interface{})
+	if env["DEBUG"] != "true" {
+		t.Errorf("booleans should stay strings, got %#v", env["DEBUG"])
+	}
+	if env["REGION"] != "us-east-1" || env["SENTRY_DSN"] != "" {
+		t.Errorf("unexpected environment: %#v", env)
+	}
+	// The remote docker interpolates the file again, so every $ is escaped
+	if env["PRICE"] != "$$5" || env["HOME_DIR"] != "$$HOME" {
+		t.Errorf("$$ should survive rendering, got PRICE=%#v HOME_DIR=%#v", env["PRICE"], env["HOME_DIR"])
+	}
+	if env["DB_HASH"] != "$$2y$$10$$abc" {
+		t.Errorf("$ in substituted values should be escaped, got %#v", env["DB_HASH"])
+	}
+
+	deploy := web.Extra["deploy"].(map[string]interface{})
+	if deploy["replicas"] != 3 {
+		t.Errorf("expected replicas to be the int 3, got %#v", deploy["replicas"])
+	}
+
+	if !reflect.DeepEqual(unset, []string{"SENTRY_DSN"}) {
+		t.Errorf("unset = %v, want [SENTRY_DSN]", unset)
+	}
+}
+
+func TestInterpolate_Required(t *testing.T) {
+	lookup := func(string) (string, bool) { return "", false }
+
+	_, _, err := Interpolate([]byte(`services:
+  web:
+    image: myapp:${IMAGE_TAG?set IMAGE_TAG in CI}
+`), lookup)
+	if err == nil {
+		t.Fatal("expected error for missing required variable")
+	}
+	if !strings.Contains(err.Error(), "services.web.image") || !strings.Contains(err.Error(), "set IMAGE_TAG in CI") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestInterpolate_NoVariables(t *testing.T) {
+	data := []byte("services:\n  web:\n    image: nginx # pinned\n")
+	out, _, err := Interpolate(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(data) {
+		t.Errorf("content without variables should be unchanged, got:\n%s", out)
+	}
+}
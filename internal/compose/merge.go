@@ -0,0 +1,244 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Merge parses several compose documents and merges them in order, each
+// one overriding the previous, following docker compose merge rules:
+//
+//   - services, networks, volumes, secrets and configs merge by name
+//   - mappings merge key by key and scalars are replaced
+//   - ports merge by target port and protocol; volumes, secrets and
+//     configs inside a service merge by target path
+//   - environment, labels, depends_on and similar list-or-map keys merge
+//     by key
+//   - command, entrypoint and healthcheck.test are replaced
+//   - any other list is appended to
+func Merge(documents ...[]byte) (*Project, error) {
+	var merged map[string]interface{}
+	for i, data := range documents {
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse compose file %d: %w", i+1, err)
+		}
+		if merged == nil {
+			merged = doc
+			continue
+		}
+		merged = mergeMapping(merged, doc, "")
+	}
+
+	out, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render merged compose file: %w", err)
+	}
+	return Parse(out)
+}
+
+// LoadFiles reads the compose files at paths and merges them in order
+func LoadFiles(paths ...string) (*Project, error) {
+	documents := make([][]byte, 0, len(paths))
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read compose file: %w", err)
+		}
+		documents = append(documents, data)
+	}
+	return Merge(documents...)
+}
+
+// mergeMapping overlays override onto base. keyPath is the slash-separated
+// location of the mapping relative to a service (or the top level), used
+// to pick the merge rule for each key.
+func mergeMapping(base, override map[string]interface{}, keyPath string) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{})
+	}
+	for key, value := range override {
+		current, ok := base[key]
+		if !ok || current == nil || value == nil {
+			base[key] = value
+			continue
+		}
+		base[key] = mergeValue(current, value, joinKey(keyPath, key))
+	}
+	return base
+}
+
+// mergeValue merges a single value according to the rule for its path
+func mergeValue(base, override interface{}, keyPath string) interface{} {
+	// Inside services.<name>, rules are keyed by the keyPath below the service
+	rel := keyPath
+	if strings.HasPrefix(keyPath, "services/") {
+		parts := strings.SplitN(keyPath, "/", 3)
+		if len(parts) < 3 {
+			return mergeMapping(asMap(base), asMap(override), keyPath)
+		}
+		rel = parts[2]
+	}
+
+	switch rel {
+	case "command", "entrypoint", "healthcheck/test":
+		return override
+	case "ports":
+		return mergeKeyed(base, override, portKey)
+	case "volumes", "secrets", "configs":
+		if keyPath == rel {
+			// Top-level definitions merge by name
+			break
+		}
+		if rel == "volumes" {
+			return mergeKeyed(base, override, volumeKey)
+		}
+		return mergeKeyed(base, override, fileReferenceKey)
+	case "environment", "labels", "deploy/labels", "build/args", "sysctls":
+		return mergeMapping(listToMap(base), listToMap(override), keyPath)
+	case "depends_on", "networks":
+		if keyPath == rel {
+			break
+		}
+		return mergeMapping(namesToMap(base), namesToMap(override), keyPath)
+	}
+
+	baseMap, baseIsMap := base.(map[string]interface{})
+	overrideMap, overrideIsMap := override.(map[string]interface{})
+	if baseIsMap && overrideIsMap {
+		return mergeMapping(baseMap, overrideMap, keyPath)
+	}
+
+	baseList, baseIsList := base.([]interface{})
+	overrideList, overrideIsList := override.([]interface{})
+	if baseIsList && overrideIsList {
+		return append(baseList, overrideList...)
+	}
+
+	return override
+}
+
+// mergeKeyed merges two lists where items with the same key replace
+// each other, keeping the position of the first occurrence
+func mergeKeyed(base, override interface{}, key func(interface{}) string) interface{} {
+	baseList, _ := base.([]interface{})
+	overrideList, ok := override.([]interface{})
+	if !ok {
+		return override
+	}
+
+	index := make(map[string]int, len(baseList))
+	result := make([]interface{}, 0, len(baseList)+len(overrideList))
+	for _, item := range baseList {
+		index[key(item)] = len(result)
+		result = append(result, item)
+	}
+	for _, item := range overrideList {
+		k := key(item)
+		if i, ok := index[k]; ok {
+			result[i] = item
+			continue
+		}
+		index[k] = len(result)
+		result = append(result, item)
+	}
+	return result
+}
+
+// portKey returns the container port and protocol of a port entry,
+// e.g. "8080:80/tcp" -> "80/tcp"
+func portKey(item interface{}) string {
+	if m, ok := item.(map[string]interface{}); ok {
+		protocol := "tcp"
+		if p, ok := m["protocol"]; ok {
+			protocol = fmt.Sprint(p)
+		}
+		return fmt.Sprintf("%v/%s", m["target"], protocol)
+	}
+
+	s := fmt.Sprint(item)
+	protocol := "tcp"
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		s, protocol = s[:i], s[i+1:]
+	}
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		s = s[i+1:]
+	}
+	return s + "/" + protocol
+}
+
+// volumeKey returns the mount path of a volume entry,
+// e.g. "data:/var/lib/data:ro" -> "/var/lib/data"
+func volumeKey(item interface{}) string {
+	if m, ok := item.(map[string]interface{}); ok {
+		return path.Clean(fmt.Sprint(m["target"]))
+	}
+
+	parts := strings.Split(fmt.Sprint(item), ":")
+	if len(parts) == 1 {
+		return path.Clean(parts[0])
+	}
+	return path.Clean(parts[1])
+}
+
+// fileReferenceKey returns the target of a service secret or config,
+// which defaults to its source
+func fileReferenceKey(item interface{}) string {
+	if m, ok := item.(map[string]interface{}); ok {
+		if target, ok := m["target"]; ok {
+			return fmt.Sprint(target)
+		}
+		return fmt.Sprint(m["source"])
+	}
+	return fmt.Sprint(item)
+}
+
+// listToMap converts a KEY=VALUE list to a mapping; mappings are returned as-is
+func listToMap(v interface{}) map[string]interface{} {
+	list, ok := v.([]interface{})
+	if !ok {
+		return asMap(v)
+	}
+
+	m := make(map[string]interface{}, len(list))
+	for _, item := range list {
+		s := fmt.Sprint(item)
+		if key, value, found := strings.Cut(s, "="); found {
+			m[key] = value
+		} else {
+			m[s] = nil
+		}
+	}
+	return m
+}
+
+// namesToMap converts a list of names to a mapping with empty values;
+// mappings are returned as-is
+func namesToMap(v interface{}) map[string]interface{} {
+	list, ok := v.([]interface{})
+	if !ok {
+		return asMap(v)
+	}
+
+	m := make(map[string]interface{}, len(list))
+	for _, item := range list {
+		m[fmt.Sprint(item)] = nil
+	}
+	return m
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func joinKey(keyPath, key string) string {
+	if keyPath == "" {
+		return key
+	}
+	return keyPath + "/" + key
+}
@@ -0,0 +1,186 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const mergeBase = `
+version: "3.8"
+services:
+  web:
+    image: myapp:latest
+    command: ["bundle", "exec", "puma"]
+    ports:
+      - "80:3000"
+      - "443:3443"
+    volumes:
+      - uploads:/app/uploads
+      - ./config:/app/config:ro
+    environment:
+      - RAILS_ENV=production
+      - LOG_LEVEL=info
+    depends_on:
+      - redis
+    secrets:
+      - db_url
+    dns:
+      - 1.1.1.1
+    deploy:
+      replicas: 2
+      labels:
+        traefik.enable: "true"
+  redis:
+    image: redis:7
+volumes:
+  uploads: {}
+secrets:
+  db_url:
+    external: true
+`
+
+const mergeOverride = `
+services:
+  web:
+    image: myapp:v2
+    command: ["bin/start"]
+    ports:
+      - "8080:3000"
+    volumes:
+      - type: bind
+        source: ./config.prod
+        target: /app/config
+    environment:
+      LOG_LEVEL: warn
+      SENTRY_DSN: https://sentry
+    depends_on:
+      postgres:
+        condition: service_healthy
+    secrets:
+      - source: db_url_v2
+        target: db_url
+    dns:
+      - 8.8.8.8
+    deploy:
+      replicas: 4
+  postgres:
+    image: postgres:16
+volumes:
+  pgdata: {}
+`
+
+func TestMerge(t *testing.T) {
+	p, err := Merge([]byte(mergeBase), []byte(mergeOverride))
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if names := p.ServiceNames(); !reflect.DeepEqual(names, []string{"postgres", "redis", "web"}) {
+		t.Errorf("unexpected services: %v", names)
+	}
+
+	web := p.Services["web"]
+	if web.Image != "myapp:v2" {
+		t.Errorf("expected image override, got %s", web.Image)
+	}
+	if !reflect.DeepEqual(web.Extra["command"], []interface{}{"bin/start"}) {
+		t.Errorf("command should be replaced, got %v", web.Extra["command"])
+	}
+
+	// Ports merge by target: 3000 replaced, 3443 kept
+	if !reflect.DeepEqual(web.Extra["ports"], []interface{}{"8080:3000", "443:3443"}) {
+		t.Errorf("unexpected ports: %v", web.Extra["ports"])
+	}
+
+	// Volumes merge by mount path
+	volumes := web.Extra["volumes"].([]interface{})
+	if len(volumes) != 2 || volumes[0] != "uploads:/app/uploads" {
+		t.Fatalf("unexpected volumes: %v", volumes)
+	}
+	if bind, ok := volumes[1].(map[string]interface{}); !ok || bind["source"] != "./config.prod" {
+		t.Errorf("expected /app/config to be replaced, got %v", volumes[1])
+	}
+
+	env := web.Extra["environment"].(map[string]interface{})
+	if env["RAILS_ENV"] != "production" || env["LOG_LEVEL"] != "warn" || env["SENTRY_DSN"] != "https://sentry" {
+		t.Errorf("unexpected environment: %v", env)
+	}
+
+	if _, ok := web.DependsOn["redis"]; !ok {
+		t.Error("depends_on should keep redis")
+	}
+	if web.DependsOn["postgres"].Condition != "service_healthy" {
+		t.Errorf("depends_on should add postgres, got %+v", web.DependsOn)
+	}
+
+	if len(web.Secrets) != 1 || web.Secrets[0].Source != "db_url_v2" {
+		t.Errorf("secrets should merge by target, got %+v", web.Secrets)
+	}
+
+	if !reflect.DeepEqual(web.Extra["dns"], []interface{}{"1.1.1.1", "8.8.8.8"}) {
+		t.Errorf("other lists should append, got %v", web.Extra["dns"])
+	}
+
+	deploy := web.Extra["deploy"].(map[string]interface{})
+	if deploy["replicas"] != 4 {
+		t.Errorf("expected replicas 4, got %v", deploy["replicas"])
+	}
+	if labels := deploy["labels"].(map[string]interface{}); labels["traefik.enable"] != "true" {
+		t.Errorf("deploy labels should be kept, got %v", labels)
+	}
+
+	if _, ok := p.Volumes["uploads"]; !ok {
+		t.Error("top-level volumes should keep uploads")
+	}
+	if _, ok := p.Volumes["pgdata"]; !ok {
+		t.Error("top-level volumes should add pgdata")
+	}
+	if !p.Secrets["db_url"].External {
+		t.Error("top-level secrets should be kept")
+	}
+}
+
+func TestMerge_PortKeys(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{"80:3000", "3000/tcp"},
+		{"127.0.0.1:80:3000", "3000/tcp"},
+		{"53:53/udp", "53/udp"},
+		{3000, "3000/tcp"},
+		{map[string]interface{}{"target": 3000, "published": 80}, "3000/tcp"},
+		{map[string]interface{}{"target": 53, "protocol": "udp"}, "53/udp"},
+	}
+	for _, tt := range tests {
+		if got := portKey(tt.in); got != tt.want {
+			t.Errorf("portKey(%v) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := filepath.Join(tmpDir, "docker-compose.yaml")
+	override := filepath.Join(tmpDir, "docker-compose.production.yaml")
+	if err := os.WriteFile(base, []byte(mergeBase), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(override, []byte(mergeOverride), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadFiles(base, override)
+	if err != nil {
+		t.Fatalf("LoadFiles failed: %v", err)
+	}
+	if p.Services["web"].Image != "myapp:v2" {
+		t.Errorf("expected merged image, got %s", p.Services["web"].Image)
+	}
+
+	if _, err := LoadFiles(base, filepath.Join(tmpDir, "missing.yaml")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
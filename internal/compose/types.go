@@ -0,0 +1,160 @@
+package compose
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DependsOn maps dependency service names to their options.
+// The short list syntax decodes to entries with no options.
+type DependsOn map[string]Dependency
+
+// Dependency holds the options of a depends_on entry
+type Dependency struct {
+	Condition string                 `yaml:"condition,omitempty"`
+	Extra     map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML accepts both the list and the mapping syntax
+func (d *DependsOn) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := value.Decode(&names); err != nil {
+			return err
+		}
+		*d = make(DependsOn, len(names))
+		for _, name := range names {
+			(*d)[name] = Dependency{}
+		}
+		return nil
+	case yaml.MappingNode:
+		m := make(map[string]Dependency)
+		if err := value.Decode(&m); err != nil {
+			return err
+		}
+		*d = m
+		return nil
+	}
+	return fmt.Errorf("line %d: depends_on must be a list or a mapping", value.Line)
+}
+
+// MarshalYAML renders the list syntax when no entry has options
+func (d DependsOn) MarshalYAML() (interface{}, error) {
+	for _, dep := range d {
+		if dep.Condition != "" || len(dep.Extra) > 0 {
+			return map[string]Dependency(d), nil
+		}
+	}
+	return sortedKeys(d), nil
+}
+
+// ServiceNetworks maps network names to the service's per-network
+// options (aliases, ipv4_address, ...). The short list syntax decodes
+// to entries with nil options.
+type ServiceNetworks map[string]map[string]interface{}
+
+// UnmarshalYAML accepts both the list and the mapping syntax
+func (n *ServiceNetworks) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := value.Decode(&names); err != nil {
+			return err
+		}
+		*n = make(ServiceNetworks, len(names))
+		for _, name := range names {
+			(*n)[name] = nil
+		}
+		return nil
+	case yaml.MappingNode:
+		m := make(map[string]map[string]interface{})
+		if err := value.Decode(&m); err != nil {
+			return err
+		}
+		*n = m
+		return nil
+	}
+	return fmt.Errorf("line %d: networks must be a list or a mapping", value.Line)
+}
+
+// MarshalYAML renders the list syntax when no entry has options
+func (n ServiceNetworks) MarshalYAML() (interface{}, error) {
+	for _, opts := range n {
+		if len(opts) > 0 {
+			return map[string]map[string]interface{}(n), nil
+		}
+	}
+	return sortedKeys(n), nil
+}
+
+// FileReference is a service's reference to a top-level secret or
+// config. The short syntax is just the source name.
+type FileReference struct {
+	Source string                 `yaml:"source"`
+	Target string                 `yaml:"target,omitempty"`
+	Extra  map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML accepts both the short and the long syntax
+func (f *FileReference) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		f.Source = value.Value
+		return nil
+	}
+	type plain FileReference
+	return value.Decode((*plain)(f))
+}
+
+// MarshalYAML renders the short syntax when only the source is set
+func (f FileReference) MarshalYAML() (interface{}, error) {
+	if f.Target == "" && len(f.Extra) == 0 {
+		return f.Source, nil
+	}
+	type plain FileReference
+	return plain(f), nil
+}
+
+// EnvFiles is a service's env_file list. A single string decodes to
+// a one-element list.
+type EnvFiles []EnvFile
+
+// EnvFile is a single env_file entry
+type EnvFile struct {
+	Path     string `yaml:"path"`
+	Required *bool  `yaml:"required,omitempty"`
+}
+
+// UnmarshalYAML accepts a string or a list
+func (e *EnvFiles) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*e = EnvFiles{{Path: value.Value}}
+		return nil
+	}
+	var files []EnvFile
+	if err := value.Decode(&files); err != nil {
+		return err
+	}
+	*e = files
+	return nil
+}
+
+// UnmarshalYAML accepts both the short and the long syntax
+func (e *EnvFile) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		e.Path = value.Value
+		return nil
+	}
+	type plain EnvFile
+	return value.Decode((*plain)(e))
+}
+
+// MarshalYAML renders the short syntax when only the path is set
+func (e EnvFile) MarshalYAML() (interface{}, error) {
+	if e.Required == nil {
+		return e.Path, nil
+	}
+	type plain EnvFile
+	return plain(e), nil
+}
@@ -1,5 +1,16 @@
 package config
 
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
 // DeploymentMode represents the deployment mode (swarm or compose)
 type DeploymentMode string
 
@@ -12,34 +23,217 @@ const (
 
 // Config represents the swarm.yaml configuration
 type Config struct {
-	Stack       string                `yaml:"stack"`
-	Mode        DeploymentMode        `yaml:"mode"`
-	SSH         SSHConfig             `yaml:"ssh"`
-	Registry    Registry              `yaml:"registry"`
-	Secrets     []string              `yaml:"secrets"`
-	Accessories []string              `yaml:"accessories"`
-	ComposeFile string                `yaml:"compose_file"`
-	Nodes       map[string]NodeConfig `yaml:"nodes"`
+	Stack       string                   `yaml:"stack" desc:"Stack name used for services, secrets and history"`
+	Mode        DeploymentMode           `yaml:"mode" desc:"Deployment mode" enum:"swarm,compose"`
+	SSH         SSHConfig                `yaml:"ssh" desc:"SSH connection to the manager node; omit to run locally"`
+	Registry    Registry                 `yaml:"registry" desc:"Container registry credentials"`
+	Secrets     SecretList               `yaml:"secrets" desc:"Secrets created as <stack>_<name>_<hash> before deploying"`
+	Accessories []string                 `yaml:"accessories" desc:"Auxiliary services managed with the accessory command"`
+	ComposeFile ComposeFiles             `yaml:"compose_file" desc:"Compose file, or list of files merged in order, relative to swarm.yaml"`
+	Nodes       map[string]NodeConfig    `yaml:"nodes" desc:"Per-node SSH settings, keyed by node hostname"`
+	Env         map[string]string        `yaml:"env" desc:"Variables used to interpolate the compose file"`
+	EnvFile     string                   `yaml:"env_file" desc:"File with secret values and compose variables, relative to swarm.yaml; defaults to .env.<destination>, then .env"`
+	Configs     map[string]string        `yaml:"configs" desc:"Docker configs created from local files, keyed by the name used in the compose file"`
+	Services    map[string]ServiceConfig `yaml:"services" desc:"Per-service settings, keyed by compose service name"`
+	Timeouts    Timeouts                 `yaml:"timeouts" desc:"Limits on how long commands run on the target may take"`
+}
+
+// ComposeFiles lists compose files merged in order, later files
+// overriding earlier ones. It accepts a single path or a list.
+type ComposeFiles []string
+
+// UnmarshalYAML accepts both `compose_file: a.yaml` and a list of paths
+func (f *ComposeFiles) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		if value.Value == "" {
+			*f = nil
+			return nil
+		}
+		*f = ComposeFiles{value.Value}
+		return nil
+	case yaml.SequenceNode:
+		var files []string
+		if err := value.Decode(&files); err != nil {
+			return err
+		}
+		*f = files
+		return nil
+	}
+	return fmt.Errorf("line %d: compose_file must be a path or a list of paths", value.Line)
+}
+
+// JSONSchema describes the accepted shapes of compose_file
+func (f ComposeFiles) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+}
+
+// Timeouts limits how long commands run on the target may take. Zero
+// disables a limit.
+type Timeouts struct {
+	Command     Duration `yaml:"command" desc:"Limit for each command, e.g. 30s or 10m; 0 disables it (default: 10m)"`
+	Stream      Duration `yaml:"stream" desc:"Limit for streamed commands such as logs -f; 0 disables it (default: 0)"`
+	Interactive Duration `yaml:"interactive" desc:"Limit for interactive commands such as exec; 0 disables it (default: 0)"`
+}
+
+// DefaultCommandTimeout is the default limit for each command
+const DefaultCommandTimeout = 10 * time.Minute
+
+// Duration is a time.Duration written as a Go duration string such as
+// 30s, 10m or 1h30m
+type Duration time.Duration
+
+// UnmarshalYAML parses a duration string; a bare 0 is also accepted
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: duration must be a string such as 30s or 10m", value.Line)
+	}
+	parsed, err := time.ParseDuration(value.Value)
+	if err != nil || parsed < 0 {
+		return fmt.Errorf("line %d: invalid duration '%s': use a value such as 30s or 10m", value.Line, value.Value)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// JSONSchema describes the accepted shapes of a duration
+func (d Duration) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string", "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`},
+			map[string]interface{}{"type": "integer", "enum": []int{0}},
+		},
+	}
+}
+
+// ServiceConfig holds settings for a compose service
+type ServiceConfig struct {
+	Secrets []string `yaml:"secrets" desc:"Secrets from the secrets section attached to the service at /run/secrets/<name>"`
 }
 
 // NodeConfig holds SSH settings for a specific node
 type NodeConfig struct {
-	User string `yaml:"user"`
+	User string `yaml:"user" desc:"SSH user for this node"`
 }
 
 // SSHConfig holds SSH connection settings
 type SSHConfig struct {
-	Host string `yaml:"host"`
-	User string `yaml:"user"`
-	Port int    `yaml:"port"`
-	Key  string `yaml:"key"`
+	Host      string    `yaml:"host" desc:"Manager hostname or IP"`
+	User      string    `yaml:"user" desc:"SSH user"`
+	Port      int       `yaml:"port" desc:"SSH port"`
+	Key       string    `yaml:"key" desc:"Path to the private key"`
+	ProxyJump JumpHosts `yaml:"proxy_jump" desc:"Bastion host, or chain of hosts in order, the manager is reached through"`
+
+	// Host key settings from ~/.ssh/config, which swarm.yaml has no keys for
+	KnownHostsFiles       []string `yaml:"-"`
+	StrictHostKeyChecking string   `yaml:"-"`
+}
+
+// JumpHost is a host an SSH connection is made through. Unset fields
+// default to the user and key of the ssh section and port 22.
+type JumpHost struct {
+	Host string `yaml:"host" desc:"Jump hostname or IP"`
+	User string `yaml:"user" desc:"SSH user; defaults to ssh.user"`
+	Port int    `yaml:"port" desc:"SSH port; defaults to 22"`
+	Key  string `yaml:"key" desc:"Path to the private key; defaults to ssh.key"`
+}
+
+// UnmarshalYAML accepts both a [user@]host[:port] string and a mapping
+func (j *JumpHost) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		// Decode through a type without this method to avoid recursion
+		type plain JumpHost
+		return value.Decode((*plain)(j))
+	}
+
+	hop, err := ParseJumpHost(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*j = hop
+	return nil
+}
+
+// JSONSchema describes the accepted shapes of a jump host
+func (j JumpHost) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			structSchema(reflect.TypeOf(j)),
+		},
+	}
+}
+
+// ParseJumpHost parses a jump host written as [user@]host[:port], as in
+// ssh -J
+func ParseJumpHost(s string) (JumpHost, error) {
+	var hop JumpHost
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		hop.User, s = s[:i], s[i+1:]
+	}
+	hop.Host = s
+
+	host, port, err := net.SplitHostPort(s)
+	if err == nil {
+		hop.Host = host
+		if hop.Port, err = strconv.Atoi(port); err != nil {
+			return JumpHost{}, fmt.Errorf("invalid jump host port '%s'", port)
+		}
+	}
+	if hop.Host == "" {
+		return JumpHost{}, fmt.Errorf("invalid jump host '%s': host is required", s)
+	}
+	return hop, nil
+}
+
+// JumpHosts lists jump hosts in the order they are connected through. It
+// accepts a single host or a list.
+type JumpHosts []JumpHost
+
+// UnmarshalYAML accepts both a single jump host and a list
+func (j *JumpHosts) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		var hops []JumpHost
+		if err := value.Decode(&hops); err != nil {
+			return err
+		}
+		*j = hops
+		return nil
+	}
+
+	if value.Kind == yaml.ScalarNode && value.Value == "" {
+		*j = nil
+		return nil
+	}
+	var hop JumpHost
+	if err := value.Decode(&hop); err != nil {
+		return err
+	}
+	*j = JumpHosts{hop}
+	return nil
+}
+
+// JSONSchema describes the accepted shapes of proxy_jump
+func (j JumpHosts) JSONSchema() map[string]interface{} {
+	hop := JumpHost{}.JSONSchema()
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			hop,
+			map[string]interface{}{"type": "array", "items": hop},
+		},
+	}
 }
 
 // Registry holds container registry settings
 type Registry struct {
-	URL      string `yaml:"url"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
+	URL      string `yaml:"url" desc:"Registry URL, e.g. ghcr.io"`
+	Username string `yaml:"username" desc:"Registry username"`
+	Password string `yaml:"password" desc:"Registry password; prefer SWARMCTL_REGISTRY_PASSWORD"`
 }
 
 // NewConfig returns a Config with default values
@@ -49,6 +243,9 @@ func NewConfig() *Config {
 		SSH: SSHConfig{
 			Port: 22,
 		},
-		ComposeFile: "docker-compose.yaml",
+		ComposeFile: ComposeFiles{"docker-compose.yaml"},
+		Timeouts: Timeouts{
+			Command: Duration(DefaultCommandTimeout),
+		},
 	}
 }
@@ -1,11 +1,31 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
+// TestMain points HOME at an empty directory, so loading a config with
+// ssh.host never reads the developer's ~/.ssh/config. Tests that need one
+// set HOME themselves with writeSSHConfig.
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "swarmctl-config-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv("HOME", home)
+
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
 func TestNewConfig(t *testing.T) {
 	cfg := NewConfig()
 
@@ -13,8 +33,8 @@ func TestNewConfig(t *testing.T) {
 		t.Errorf("expected default SSH port 22, got %d", cfg.SSH.Port)
 	}
 
-	if cfg.ComposeFile != "docker-compose.yaml" {
-		t.Errorf("expected default compose file 'docker-compose.yaml', got %s", cfg.ComposeFile)
+	if len(cfg.ComposeFile) != 1 || cfg.ComposeFile[0] != "docker-compose.yaml" {
+		t.Errorf("expected default compose file 'docker-compose.yaml', got %v", cfg.ComposeFile)
 	}
 }
 
@@ -35,6 +55,10 @@ secrets:
 accessories:
   - redis
 compose_file: docker-compose.yaml
+services:
+  web:
+    secrets:
+      - API_KEY
 `
 	swarmPath := filepath.Join(tmpDir, "swarm.yaml")
 	if err := os.WriteFile(swarmPath, []byte(swarmYaml), 0644); err != nil {
@@ -76,9 +100,13 @@ compose_file: docker-compose.yaml
 		t.Errorf("expected 1 accessory, got %d", len(cfg.Accessories))
 	}
 
+	if got := cfg.Services["web"].Secrets; len(got) != 1 || got[0] != "API_KEY" {
+		t.Errorf("expected web to use API_KEY, got %v", got)
+	}
+
 	// Compose file path should be absolute now
-	if !filepath.IsAbs(cfg.ComposeFile) {
-		t.Errorf("expected absolute compose file path, got '%s'", cfg.ComposeFile)
+	if !filepath.IsAbs(cfg.ComposeFile[0]) {
+		t.Errorf("expected absolute compose file path, got '%s'", cfg.ComposeFile[0])
 	}
 }
 
@@ -222,3 +250,185 @@ func TestLoadComposeFileNotFound(t *testing.T) {
 		t.Error("expected error for nonexistent file")
 	}
 }
+
+func TestLoadComposeFileList(t *testing.T) {
+	swarmPath := writeDestinationFixture(t, `
+stack: myapp
+compose_file:
+  - docker-compose.yaml
+  - /abs/docker-compose.override.yaml
+`, `
+compose_file: !append
+  - docker-compose.staging.yaml
+`)
+	dir := filepath.Dir(swarmPath)
+
+	cfg, err := LoadDestination(swarmPath, "staging")
+	if err != nil {
+		t.Fatalf("LoadDestination failed: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "docker-compose.yaml"),
+		"/abs/docker-compose.override.yaml",
+		filepath.Join(dir, "docker-compose.staging.yaml"),
+	}
+	if len(cfg.ComposeFile) != len(want) {
+		t.Fatalf("expected %v, got %v", want, cfg.ComposeFile)
+	}
+	for i := range want {
+		if cfg.ComposeFile[i] != want[i] {
+			t.Errorf("compose file %d = %s, want %s", i, cfg.ComposeFile[i], want[i])
+		}
+	}
+}
+
+func TestLoadComposeFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	base := filepath.Join(tmpDir, "docker-compose.yaml")
+	override := filepath.Join(tmpDir, "docker-compose.production.yaml")
+	if err := os.WriteFile(base, []byte("services:\n  web:\n    image: nginx\n    ports:\n      - \"80:80\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(override, []byte("services:\n  web:\n    image: nginx:1.27\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := LoadComposeFiles([]string{base, override})
+	if err != nil {
+		t.Fatalf("LoadComposeFiles failed: %v", err)
+	}
+	if !strings.Contains(string(data), "image: nginx:1.27") || !strings.Contains(string(data), "80:80") {
+		t.Errorf("expected merged compose file, got:\n%s", data)
+	}
+
+	if _, err := LoadComposeFiles(nil); err == nil {
+		t.Error("expected error when no compose file is configured")
+	}
+}
+
+func TestLoadTimeouts(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	swarmPath := filepath.Join(tmpDir, "swarm.yaml")
+	swarmYaml := "stack: myapp\ntimeouts:\n  stream: 1h30m\n  interactive: 0\n"
+	if err := os.WriteFile(swarmPath, []byte(swarmYaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(swarmPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Timeouts.Command != Duration(DefaultCommandTimeout) {
+		t.Errorf("expected default command timeout, got %v", time.Duration(cfg.Timeouts.Command))
+	}
+	if cfg.Timeouts.Stream != Duration(90*time.Minute) {
+		t.Errorf("expected stream timeout 1h30m, got %v", time.Duration(cfg.Timeouts.Stream))
+	}
+	if cfg.Timeouts.Interactive != 0 {
+		t.Errorf("expected no interactive timeout, got %v", time.Duration(cfg.Timeouts.Interactive))
+	}
+
+	if err := os.WriteFile(swarmPath, []byte("stack: myapp\ntimeouts:\n  command: 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(swarmPath); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Timeouts.Command != 0 {
+		t.Errorf("expected command timeout disabled, got %v", time.Duration(cfg.Timeouts.Command))
+	}
+
+	if err := os.WriteFile(swarmPath, []byte("stack: myapp\ntimeouts:\n  command: 10\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(swarmPath); err == nil || !strings.Contains(err.Error(), "invalid duration") {
+		t.Errorf("expected invalid duration error, got %v", err)
+	}
+}
+
+func TestLoadProxyJump(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want JumpHosts
+	}{
+		{
+			name: "single host string",
+			yaml: "proxy_jump: bastion.example.com",
+			want: JumpHosts{{Host: "bastion.example.com"}},
+		},
+		{
+			name: "user and port in string",
+			yaml: "proxy_jump: admin@bastion.example.com:2222",
+			want: JumpHosts{{Host: "bastion.example.com", User: "admin", Port: 2222}},
+		},
+		{
+			name: "single mapping",
+			yaml: "proxy_jump:\n    host: bastion.example.com\n    user: admin\n    port: 2222",
+			want: JumpHosts{{Host: "bastion.example.com", User: "admin", Port: 2222}},
+		},
+		{
+			name: "chain",
+			yaml: "proxy_jump:\n    - edge.example.com\n    - host: 10.0.0.5\n      user: admin",
+			want: JumpHosts{{Host: "edge.example.com"}, {Host: "10.0.0.5", User: "admin"}},
+		},
+		{
+			name: "IPv6 with port",
+			yaml: "proxy_jump: '[2001:db8::1]:2222'",
+			want: JumpHosts{{Host: "2001:db8::1", Port: 2222}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			swarmPath := writeConfig(t, "stack: myapp\nssh:\n  host: manager\n  user: deploy\n  "+tt.yaml+"\n")
+
+			cfg, err := Load(swarmPath)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.SSH.ProxyJump, tt.want) {
+				t.Errorf("ProxyJump = %+v, want %+v", cfg.SSH.ProxyJump, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProxyJump_KeyExpansion(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	swarmPath := writeConfig(t, "stack: myapp\nssh:\n  host: manager\n  proxy_jump:\n    host: bastion\n    key: ~/.ssh/bastion\n")
+
+	cfg, err := Load(swarmPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if want := filepath.Join(home, ".ssh", "bastion"); cfg.SSH.ProxyJump[0].Key != want {
+		t.Errorf("expected key %s, got %s", want, cfg.SSH.ProxyJump[0].Key)
+	}
+}
+
+func TestLoadProxyJump_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"bad port", "proxy_jump: bastion:ssh", "invalid jump host port"},
+		{"unknown key in mapping", "proxy_jump:\n    host: bastion\n    usr: admin", `unknown key "usr" in ssh.proxy_jump`},
+		{"unknown key in chain", "proxy_jump:\n    - host: bastion\n      prot: 22", `unknown key "prot" in ssh.proxy_jump[0]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			swarmPath := writeConfig(t, "stack: myapp\nssh:\n  host: manager\n  "+tt.yaml+"\n")
+
+			if _, err := Load(swarmPath); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/marcelsud/swarmctl/internal/dotenv"
+	"github.com/marcelsud/swarmctl/internal/interpolate"
+)
+
+// ComposeLookup returns the lookup used to interpolate compose files.
+// Variables are resolved from the process environment first, then from
+// envFile (skipped if it does not exist), then from the env section.
+func (c *Config) ComposeLookup(envFile string) (interpolate.LookupFunc, error) {
+	fileEnv := map[string]string{}
+	if envFile != "" {
+		if _, err := os.Stat(envFile); err == nil {
+			env, err := dotenv.Read(envFile)
+			if err != nil {
+				return nil, err
+			}
+			fileEnv = env
+		}
+	}
+
+	return func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		if value, ok := fileEnv[name]; ok {
+			return value, true
+		}
+		value, ok := c.Env[name]
+		return value, ok
+	}, nil
+}
+
+// EnvFilePath returns the .env file for a destination: env_file from
+// swarm.yaml if set, otherwise .env.<destination> in dir if it exists,
+// otherwise .env in dir
+func (c *Config) EnvFilePath(dir, destination string) string {
+	if c.EnvFile != "" {
+		return c.EnvFile
+	}
+	if destination != "" {
+		path := filepath.Join(dir, ".env."+destination)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, ".env")
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestComposeLookup(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env.staging")
+	if err := os.WriteFile(envFile, []byte("IMAGE_TAG=from-file\nREGION=eu-west-1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("IMAGE_TAG", "from-process")
+
+	cfg := &Config{Env: map[string]string{
+		"IMAGE_TAG": "from-config",
+		"REGION":    "us-east-1",
+		"REPLICAS":  "2",
+	}}
+
+	lookup, err := cfg.ComposeLookup(envFile)
+	if err != nil {
+		t.Fatalf("ComposeLookup failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"IMAGE_TAG": "from-process",
+		"REGION":    "eu-west-1",
+		"REPLICAS":  "2",
+	}
+	for name, want := range tests {
+		if got, ok := lookup(name); !ok || got != want {
+			t.Errorf("lookup(%s) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+
+	if _, ok := lookup("SWARMCTL_TEST_UNDEFINED"); ok {
+		t.Error("undefined variable should not be set")
+	}
+}
+
+func TestComposeLookup_MissingEnvFile(t *testing.T) {
+	cfg := &Config{Env: map[string]string{"A": "1"}}
+
+	lookup, err := cfg.ComposeLookup(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("missing env file should be ignored: %v", err)
+	}
+	if got, _ := lookup("A"); got != "1" {
+		t.Errorf("expected A=1 from env section, got %q", got)
+	}
+}
+
+func TestEnvFilePath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env.staging"), []byte("A=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		envFile     string
+		destination string
+		want        string
+	}{
+		{"no destination", "", "", filepath.Join(dir, ".env")},
+		{"destination file exists", "", "staging", filepath.Join(dir, ".env.staging")},
+		{"destination file missing", "", "production", filepath.Join(dir, ".env")},
+		{"env_file wins", "/secrets/app.env", "staging", "/secrets/app.env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{EnvFile: tt.envFile}
+			if got := cfg.EnvFilePath(dir, tt.destination); got != tt.want {
+				t.Errorf("EnvFilePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvFileRelativeToConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.yaml")
+	if err := os.WriteFile(path, []byte("stack: myapp\nenv_file: secrets/app.env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if want := filepath.Join(dir, "secrets/app.env"); cfg.EnvFile != want {
+		t.Errorf("EnvFile = %q, want %q", cfg.EnvFile, want)
+	}
+}
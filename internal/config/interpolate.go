@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/marcelsud/swarmctl/internal/interpolate"
+	"gopkg.in/yaml.v3"
+)
+
+// interpolateNode expands ${VAR}, ${VAR:-default} and ${VAR:?error} in
+// every scalar value under node. Bare $VAR is left as-is so existing
+// values containing a literal $ keep working. Failures are collected in
+// ve with the path of the offending value.
+func interpolateNode(node *yaml.Node, path string, lookup interpolate.LookupFunc, ve *ValidationError) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			interpolateNode(node.Content[i+1], joinPath(path, node.Content[i].Value), lookup, ve)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			interpolateNode(item, indexPath(path, i), lookup, ve)
+		}
+	case yaml.ScalarNode:
+		value, err := interpolate.ExpandBraced(node.Value, lookup)
+		if err != nil {
+			ve.Add(fmt.Sprintf("%s: %v", path, err))
+			return
+		}
+		if value == node.Value {
+			return
+		}
+		node.Value = value
+		// Let plain scalars re-resolve so ${PORT} can fill an int field
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	}
+}
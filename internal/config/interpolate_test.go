@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	swarmPath := filepath.Join(t.TempDir(), "swarm.yaml")
+	if err := os.WriteFile(swarmPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return swarmPath
+}
+
+func TestLoad_Interpolation(t *testing.T) {
+	t.Setenv("DEPLOY_HOST", "ci.example.com")
+	t.Setenv("DEPLOY_STACK", "myapp-pr-42")
+	t.Setenv("REGISTRY_USER", "ci-bot")
+	t.Setenv("WORKER_USER", "ubuntu")
+
+	swarmPath := writeConfig(t, `
+stack: ${DEPLOY_STACK}
+ssh:
+  host: ${DEPLOY_HOST}
+  user: ${DEPLOY_USER:-deploy}
+  port: ${DEPLOY_PORT:-2222}
+registry:
+  url: ${REGISTRY_URL:-ghcr.io}
+  username: ${REGISTRY_USER}
+nodes:
+  worker-1:
+    user: ${WORKER_USER}
+secrets:
+  - API_KEY
+`)
+
+	cfg, err := Load(swarmPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Stack != "myapp-pr-42" {
+		t.Errorf("expected stack 'myapp-pr-42', got '%s'", cfg.Stack)
+	}
+	if cfg.SSH.Host != "ci.example.com" {
+		t.Errorf("expected SSH host 'ci.example.com', got '%s'", cfg.SSH.Host)
+	}
+	if cfg.SSH.User != "deploy" {
+		t.Errorf("expected SSH user 'deploy', got '%s'", cfg.SSH.User)
+	}
+	if cfg.SSH.Port != 2222 {
+		t.Errorf("expected SSH port 2222, got %d", cfg.SSH.Port)
+	}
+	if cfg.Registry.URL != "ghcr.io" || cfg.Registry.Username != "ci-bot" {
+		t.Errorf("unexpected registry: %s / %s", cfg.Registry.URL, cfg.Registry.Username)
+	}
+	if cfg.Nodes["worker-1"].User != "ubuntu" {
+		t.Errorf("expected node user 'ubuntu', got '%s'", cfg.Nodes["worker-1"].User)
+	}
+}
+
+func TestLoad_InterpolationKeepsLiteralDollar(t *testing.T) {
+	swarmPath := writeConfig(t, `
+stack: myapp
+ssh:
+  host: example.com
+registry:
+  password: p@$word$$
+`)
+
+	cfg, err := Load(swarmPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Registry.Password != "p@$word$$" {
+		t.Errorf("expected password 'p@$word$$', got '%s'", cfg.Registry.Password)
+	}
+}
+
+func TestLoad_InterpolationRequired(t *testing.T) {
+	swarmPath := writeConfig(t, `
+stack: myapp
+ssh:
+  host: ${DEPLOY_HOST:?set it in CI}
+registry:
+  username: ${REGISTRY_USER?}
+`)
+
+	_, err := Load(swarmPath)
+	if err == nil {
+		t.Fatal("expected error for missing required variables")
+	}
+
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+	if len(ve.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %v", ve.Errors)
+	}
+	if !strings.Contains(ve.Errors[0], "ssh.host") || !strings.Contains(ve.Errors[0], "DEPLOY_HOST") || !strings.Contains(ve.Errors[0], "set it in CI") {
+		t.Errorf("unexpected error message: %s", ve.Errors[0])
+	}
+	if !strings.Contains(ve.Errors[1], "registry.username") {
+		t.Errorf("unexpected error message: %s", ve.Errors[1])
+	}
+}
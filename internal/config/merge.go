@@ -0,0 +1,285 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// appendTag marks a list in a destination file that should be appended
+// to the base list instead of replacing it
+const appendTag = "!append"
+
+// Document is a configuration resolved from a base file and an optional
+// destination overlay
+type Document struct {
+	// Path is the base config path, used to resolve relative paths
+	Path string
+	// Files lists the files that were merged, base first
+	Files []string
+
+	root    *yaml.Node
+	origins map[string]string
+}
+
+// Resolve reads the base config at path and, if destination is set,
+// deep-merges swarm.<destination>.yaml on top of it.
+//
+// Merge rules:
+//   - mappings are merged key by key
+//   - scalars in the destination file override the base value
+//   - lists in the destination file replace the base list, unless tagged
+//     with !append, in which case their items are appended to it
+//
+// If the base file does not exist but the destination file does, the
+// destination file is used on its own. Keys that do not match a Config
+// field are reported as a ValidationError.
+func Resolve(path, destination string) (*Document, error) {
+	doc := &Document{Path: path, origins: make(map[string]string)}
+
+	var files []string
+	if destination == "" {
+		files = []string{path}
+	} else {
+		destPath := DestinationPath(path, destination)
+		if _, err := os.Stat(destPath); err != nil {
+			return nil, fmt.Errorf("destination config not found: %s", destPath)
+		}
+		if _, err := os.Stat(path); err == nil {
+			files = []string{path, destPath}
+		} else {
+			files = []string{destPath}
+		}
+	}
+
+	ve := &ValidationError{}
+	for _, file := range files {
+		node, err := readNode(file)
+		if err != nil {
+			return nil, err
+		}
+		checkKeys(node, reflect.TypeOf(Config{}), "", file, ve)
+
+		name := filepath.Base(file)
+		if doc.root == nil {
+			stripAppendTags(node)
+			doc.root = node
+			doc.markOrigins(node, "", name)
+		} else {
+			doc.root = doc.merge(doc.root, node, "", name)
+		}
+		doc.Files = append(doc.Files, file)
+	}
+
+	if ve.HasErrors() {
+		return nil, ve
+	}
+
+	return doc, nil
+}
+
+// Origin returns the file a value at the given path came from.
+// Paths use dots for mapping keys and [i] for list items, e.g.
+// "ssh.host" or "secrets[2]".
+func (d *Document) Origin(path string) string {
+	return d.origins[path]
+}
+
+// Redact masks the scalar value at a dotted mapping path, if present
+func (d *Document) Redact(path string) {
+	node := d.root
+	for _, key := range strings.Split(path, ".") {
+		idx := mappingIndex(node, key)
+		if idx < 0 {
+			return
+		}
+		node = resolveAlias(node.Content[idx+1])
+	}
+	if node.Kind == yaml.ScalarNode && node.Value != "" {
+		node.Value = "********"
+		node.Style = 0
+	}
+}
+
+// Annotated renders the resolved document as YAML with a trailing
+// comment on each value naming the file it came from
+func (d *Document) Annotated() ([]byte, error) {
+	root := cloneNode(d.root)
+	d.annotate(root, "")
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, fmt.Errorf("failed to render config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to render config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// merge overlays node onto base and returns the merged node
+func (d *Document) merge(base, overlay *yaml.Node, path, file string) *yaml.Node {
+	base, overlay = resolveAlias(base), resolveAlias(overlay)
+
+	switch {
+	case base.Kind == yaml.MappingNode && overlay.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(overlay.Content); i += 2 {
+			key, value := overlay.Content[i], overlay.Content[i+1]
+			childPath := joinPath(path, key.Value)
+
+			if idx := mappingIndex(base, key.Value); idx >= 0 {
+				base.Content[idx+1] = d.merge(base.Content[idx+1], value, childPath, file)
+				continue
+			}
+
+			stripAppendTags(value)
+			d.markOrigins(value, childPath, file)
+			base.Content = append(base.Content, key, value)
+		}
+		return base
+
+	case overlay.Kind == yaml.SequenceNode && overlay.Tag == appendTag:
+		overlay.Tag = "!!seq"
+		if base.Kind != yaml.SequenceNode {
+			stripAppendTags(overlay)
+			d.replaceOrigins(path)
+			d.markOrigins(overlay, path, file)
+			return overlay
+		}
+		for _, item := range overlay.Content {
+			stripAppendTags(item)
+			d.markOrigins(item, indexPath(path, len(base.Content)), file)
+			base.Content = append(base.Content, item)
+		}
+		return base
+
+	default:
+		stripAppendTags(overlay)
+		d.replaceOrigins(path)
+		d.markOrigins(overlay, path, file)
+		return overlay
+	}
+}
+
+// markOrigins records file as the origin of every leaf under node
+func (d *Document) markOrigins(node *yaml.Node, path, file string) {
+	node = resolveAlias(node)
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			d.origins[path] = file
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			d.markOrigins(node.Content[i+1], joinPath(path, node.Content[i].Value), file)
+		}
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			d.origins[path] = file
+		}
+		for i, item := range node.Content {
+			d.markOrigins(item, indexPath(path, i), file)
+		}
+	default:
+		d.origins[path] = file
+	}
+}
+
+// replaceOrigins forgets the origins of path and everything below it
+func (d *Document) replaceOrigins(path string) {
+	for p := range d.origins {
+		if p == path || isChildPath(p, path) {
+			delete(d.origins, p)
+		}
+	}
+}
+
+// annotate sets a line comment with the origin on every leaf under node
+func (d *Document) annotate(node *yaml.Node, path string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			node.LineComment = d.origins[path]
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			d.annotate(node.Content[i+1], joinPath(path, node.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			node.LineComment = d.origins[path]
+		}
+		for i, item := range node.Content {
+			d.annotate(item, indexPath(path, i))
+		}
+	case yaml.ScalarNode:
+		node.LineComment = d.origins[path]
+	}
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func stripAppendTags(node *yaml.Node) {
+	if node.Tag == appendTag {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		stripAppendTags(child)
+	}
+}
+
+// cloneNode deep-copies a node tree, resolving aliases
+func cloneNode(node *yaml.Node) *yaml.Node {
+	node = resolveAlias(node)
+	c := *node
+	c.HeadComment, c.LineComment, c.FootComment = "", "", ""
+	c.Anchor = ""
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = cloneNode(child)
+	}
+	return &c
+}
+
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+func isChildPath(p, parent string) bool {
+	if parent == "" {
+		return true
+	}
+	if len(p) <= len(parent) || p[:len(parent)] != parent {
+		return false
+	}
+	next := p[len(parent)]
+	return next == '.' || next == '['
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDestinationFixture(t *testing.T, base, overlay string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	swarmPath := filepath.Join(tmpDir, "swarm.yaml")
+	if base != "" {
+		if err := os.WriteFile(swarmPath, []byte(base), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if overlay != "" {
+		if err := os.WriteFile(filepath.Join(tmpDir, "swarm.staging.yaml"), []byte(overlay), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return swarmPath
+}
+
+const baseConfig = `
+stack: myapp
+ssh:
+  host: prod.example.com
+  user: deploy
+  port: 2222
+registry:
+  url: ghcr.io
+  username: myuser
+secrets:
+  - DATABASE_URL
+  - API_KEY
+accessories:
+  - redis
+nodes:
+  worker-1:
+    user: root
+compose_file: docker-compose.yaml
+`
+
+func TestLoadDestination_Merge(t *testing.T) {
+	swarmPath := writeDestinationFixture(t, baseConfig, `
+stack: myapp-staging
+ssh:
+  host: staging.example.com
+secrets: !append
+  - SENTRY_DSN
+accessories:
+  - postgres
+nodes:
+  worker-2:
+    user: ubuntu
+`)
+
+	cfg, err := LoadDestination(swarmPath, "staging")
+	if err != nil {
+		t.Fatalf("LoadDestination failed: %v", err)
+	}
+
+	// Scalars override
+	if cfg.Stack != "myapp-staging" {
+		t.Errorf("expected stack 'myapp-staging', got '%s'", cfg.Stack)
+	}
+	if cfg.SSH.Host != "staging.example.com" {
+		t.Errorf("expected SSH host 'staging.example.com', got '%s'", cfg.SSH.Host)
+	}
+
+	// Maps merge
+	if cfg.SSH.User != "deploy" || cfg.SSH.Port != 2222 {
+		t.Errorf("expected ssh user/port inherited from base, got %s/%d", cfg.SSH.User, cfg.SSH.Port)
+	}
+	if cfg.Registry.URL != "ghcr.io" {
+		t.Errorf("expected registry inherited from base, got '%s'", cfg.Registry.URL)
+	}
+	if len(cfg.Nodes) != 2 || cfg.Nodes["worker-1"].User != "root" || cfg.Nodes["worker-2"].User != "ubuntu" {
+		t.Errorf("expected nodes to merge, got %+v", cfg.Nodes)
+	}
+
+	// !append appends, plain lists replace
+	if strings.Join(cfg.Secrets.Names(), ",") != "DATABASE_URL,API_KEY,SENTRY_DSN" {
+		t.Errorf("expected appended secrets, got %v", cfg.Secrets)
+	}
+	if strings.Join(cfg.Accessories, ",") != "postgres" {
+		t.Errorf("expected replaced accessories, got %v", cfg.Accessories)
+	}
+
+	// Relative paths resolve against the base file's directory
+	if cfg.ComposeFile[0] != filepath.Join(filepath.Dir(swarmPath), "docker-compose.yaml") {
+		t.Errorf("unexpected compose file path: %v", cfg.ComposeFile)
+	}
+}
+
+func TestLoadDestination_NoDestination(t *testing.T) {
+	swarmPath := writeDestinationFixture(t, baseConfig, "")
+
+	cfg, err := LoadDestination(swarmPath, "")
+	if err != nil {
+		t.Fatalf("LoadDestination failed: %v", err)
+	}
+	if cfg.Stack != "myapp" {
+		t.Errorf("expected stack 'myapp', got '%s'", cfg.Stack)
+	}
+}
+
+func TestLoadDestination_MissingDestination(t *testing.T) {
+	swarmPath := writeDestinationFixture(t, baseConfig, "")
+
+	_, err := LoadDestination(swarmPath, "staging")
+	if err == nil {
+		t.Fatal("expected error for missing destination file")
+	}
+	if !strings.Contains(err.Error(), "swarm.staging.yaml") {
+		t.Errorf("error should name the destination file: %v", err)
+	}
+}
+
+func TestLoadDestination_DestinationOnly(t *testing.T) {
+	swarmPath := writeDestinationFixture(t, "", `
+stack: myapp-staging
+`)
+
+	cfg, err := LoadDestination(swarmPath, "staging")
+	if err != nil {
+		t.Fatalf("LoadDestination failed: %v", err)
+	}
+	if cfg.Stack != "myapp-staging" {
+		t.Errorf("expected stack 'myapp-staging', got '%s'", cfg.Stack)
+	}
+}
+
+func TestResolve_Origins(t *testing.T) {
+	swarmPath := writeDestinationFixture(t, baseConfig, `
+ssh:
+  host: staging.example.com
+secrets: !append
+  - SENTRY_DSN
+`)
+
+	doc, err := Resolve(swarmPath, "staging")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"stack":               "swarm.yaml",
+		"ssh.host":            "swarm.staging.yaml",
+		"ssh.user":            "swarm.yaml",
+		"secrets[0]":          "swarm.yaml",
+		"secrets[2]":          "swarm.staging.yaml",
+		"nodes.worker-1.user": "swarm.yaml",
+	}
+	for path, want := range tests {
+		if got := doc.Origin(path); got != want {
+			t.Errorf("Origin(%s) = %q, want %q", path, got, want)
+		}
+	}
+
+	out, err := doc.Annotated()
+	if err != nil {
+		t.Fatalf("Annotated failed: %v", err)
+	}
+	if !strings.Contains(string(out), "host: staging.example.com # swarm.staging.yaml") {
+		t.Errorf("annotated output missing origin comment:\n%s", out)
+	}
+	if !strings.Contains(string(out), "user: deploy # swarm.yaml") {
+		t.Errorf("annotated output missing origin comment:\n%s", out)
+	}
+}
+
+func TestResolve_ReplacedListOrigins(t *testing.T) {
+	swarmPath := writeDestinationFixture(t, baseConfig, `
+secrets:
+  - ONLY_THIS
+`)
+
+	doc, err := Resolve(swarmPath, "staging")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	if got := doc.Origin("secrets[0]"); got != "swarm.staging.yaml" {
+		t.Errorf("Origin(secrets[0]) = %q, want swarm.staging.yaml", got)
+	}
+	if got := doc.Origin("secrets[1]"); got != "" {
+		t.Errorf("replaced list should drop old origins, got %q", got)
+	}
+}
+
+func TestDocument_Redact(t *testing.T) {
+	swarmPath := writeDestinationFixture(t, `
+stack: myapp
+registry:
+  password: hunter2
+`, "")
+
+	doc, err := Resolve(swarmPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc.Redact("registry.password")
+	doc.Redact("does.not.exist")
+
+	out, err := doc.Annotated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "hunter2") {
+		t.Errorf("password should be redacted:\n%s", out)
+	}
+}
+
+func TestDestinationPath(t *testing.T) {
+	tests := []struct {
+		path, dest, want string
+	}{
+		{"swarm.yaml", "staging", "swarm.staging.yaml"},
+		{"config/app.yml", "production", "config/app.production.yml"},
+	}
+	for _, tt := range tests {
+		if got := DestinationPath(tt.path, tt.dest); got != tt.want {
+			t.Errorf("DestinationPath(%s, %s) = %s, want %s", tt.path, tt.dest, got, tt.want)
+		}
+	}
+}
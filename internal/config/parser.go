@@ -6,34 +6,94 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/marcelsud/swarmctl/internal/compose"
 	"gopkg.in/yaml.v3"
 )
 
 // Load reads and parses the swarm.yaml configuration file
 func Load(path string) (*Config, error) {
-	cfg := NewConfig()
+	return LoadDestination(path, "")
+}
 
-	data, err := os.ReadFile(path)
+// LoadDestination reads the base configuration file and, if destination
+// is set, deep-merges swarm.<destination>.yaml on top of it
+func LoadDestination(path, destination string) (*Config, error) {
+	doc, err := Resolve(path, destination)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
+	return doc.Config()
+}
+
+// Config decodes the resolved document into a Config, interpolating
+// environment variables in its values first
+func (d *Document) Config() (*Config, error) {
+	cfg := NewConfig()
+
+	if d.root != nil {
+		root := cloneNode(d.root)
+
+		ve := &ValidationError{}
+		interpolateNode(root, "", os.LookupEnv, ve)
+		if ve.HasErrors() {
+			return nil, ve
+		}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+		if err := root.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
 	}
 
 	// Normalize mode to lowercase
 	cfg.Mode = DeploymentMode(strings.ToLower(string(cfg.Mode)))
 
-	// Expand ~ in key path
+	// Expand ~ in key paths
 	if cfg.SSH.Key != "" {
 		cfg.SSH.Key = expandPath(cfg.SSH.Key)
 	}
+	for i, hop := range cfg.SSH.ProxyJump {
+		if hop.Key != "" {
+			cfg.SSH.ProxyJump[i].Key = expandPath(hop.Key)
+		}
+	}
+
+	// Fill in unset ssh settings from ~/.ssh/config
+	if err := d.applySSHConfig(cfg); err != nil {
+		return nil, err
+	}
 
-	// Resolve compose file path relative to config file
-	if cfg.ComposeFile != "" && !filepath.IsAbs(cfg.ComposeFile) {
-		configDir := filepath.Dir(path)
-		cfg.ComposeFile = filepath.Join(configDir, cfg.ComposeFile)
+	// Resolve compose file paths relative to config file
+	configDir := filepath.Dir(d.Path)
+	for i, file := range cfg.ComposeFile {
+		if !filepath.IsAbs(file) {
+			cfg.ComposeFile[i] = filepath.Join(configDir, file)
+		}
+	}
+
+	// Resolve config file paths relative to config file
+	for name, file := range cfg.Configs {
+		if !filepath.IsAbs(file) {
+			cfg.Configs[name] = filepath.Join(configDir, file)
+		}
+	}
+
+	// Resolve secret file paths relative to config file
+	for i, secret := range cfg.Secrets {
+		if secret.File != "" {
+			file := expandPath(secret.File)
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(configDir, file)
+			}
+			cfg.Secrets[i].File = file
+		}
+	}
+
+	// Resolve env file path relative to config file
+	if cfg.EnvFile != "" {
+		cfg.EnvFile = expandPath(cfg.EnvFile)
+		if !filepath.IsAbs(cfg.EnvFile) {
+			cfg.EnvFile = filepath.Join(configDir, cfg.EnvFile)
+		}
 	}
 
 	// Load registry password from environment if not set
@@ -44,6 +104,30 @@ func Load(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// DestinationPath returns the destination overlay path for a base config
+// path, e.g. swarm.yaml + staging -> swarm.staging.yaml
+func DestinationPath(path, destination string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + destination + ext
+}
+
+// LoadComposeFiles reads the compose files and merges them into a single
+// document. A single file is returned unchanged.
+func LoadComposeFiles(paths []string) ([]byte, error) {
+	switch len(paths) {
+	case 0:
+		return nil, fmt.Errorf("no compose file configured")
+	case 1:
+		return LoadComposeFile(paths[0])
+	}
+
+	project, err := compose.LoadFiles(paths...)
+	if err != nil {
+		return nil, err
+	}
+	return project.Marshal()
+}
+
 // LoadComposeFile reads the docker-compose.yaml file
 func LoadComposeFile(path string) ([]byte, error) {
 	data, err := os.ReadFile(path)
@@ -53,6 +137,29 @@ func LoadComposeFile(path string) ([]byte, error) {
 	return data, nil
 }
 
+// readNode reads a YAML file into its root mapping node
+func readNode(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse config file %s: line %d: expected a mapping at the top level", path, root.Line)
+	}
+	return root, nil
+}
+
 // expandPath expands ~ to home directory
 func expandPath(path string) string {
 	if len(path) > 0 && path[0] == '~' {
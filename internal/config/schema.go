@@ -0,0 +1,84 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// schemaID is the JSON Schema draft the generated schema conforms to
+const schemaID = "http://json-schema.org/draft-07/schema#"
+
+// Schema returns a JSON Schema for swarm.yaml generated from Config
+func Schema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	schema["$schema"] = schemaID
+	schema["title"] = "swarmctl configuration"
+	schema["patternProperties"] = map[string]interface{}{"^" + extensionPrefix: map[string]interface{}{}}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaProvider is implemented by types that accept more than one
+// YAML shape and describe their own schema
+type schemaProvider interface {
+	JSONSchema() map[string]interface{}
+}
+
+var schemaProviderType = reflect.TypeOf((*schemaProvider)(nil)).Elem()
+
+// typeSchema builds the schema for a Go type
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Implements(schemaProviderType) {
+		return reflect.Zero(t).Interface().(schemaProvider).JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem()),
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem()),
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// Strings are allowed so values can use ${VAR} interpolation
+		return map[string]interface{}{"type": []string{"integer", "string"}}
+	}
+
+	return map[string]interface{}{}
+}
+
+// structSchema builds the object schema for a struct type from its fields
+func structSchema(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	for name, f := range yamlFields(t) {
+		prop := typeSchema(f.Type)
+		if desc := f.Tag.Get("desc"); desc != "" {
+			prop["description"] = desc
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			prop["enum"] = strings.Split(enum, ",")
+		}
+		props[name] = prop
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+}
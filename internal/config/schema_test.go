@@ -0,0 +1,46 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	data, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+
+	var schema struct {
+		Schema               string `json:"$schema"`
+		AdditionalProperties bool   `json:"additionalProperties"`
+		Properties           map[string]struct {
+			Type       interface{}            `json:"type"`
+			Enum       []string               `json:"enum"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	if schema.Schema != schemaID {
+		t.Errorf("unexpected $schema: %s", schema.Schema)
+	}
+	if schema.AdditionalProperties {
+		t.Error("top level should not allow additional properties")
+	}
+
+	for _, key := range []string{"stack", "mode", "ssh", "registry", "secrets", "accessories", "compose_file", "nodes"} {
+		if _, ok := schema.Properties[key]; !ok {
+			t.Errorf("schema missing property %q", key)
+		}
+	}
+
+	if mode := schema.Properties["mode"]; len(mode.Enum) != 2 {
+		t.Errorf("expected mode enum, got %v", mode.Enum)
+	}
+	if ssh := schema.Properties["ssh"]; ssh.Properties["port"] == nil {
+		t.Error("expected ssh.port in schema")
+	}
+}
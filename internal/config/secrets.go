@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Secret providers, selecting where a secret value is read from
+const (
+	// ProviderEncrypted reads the value from the encrypted secrets file
+	ProviderEncrypted = "encrypted"
+	// ProviderDotenv reads the value from the env file
+	ProviderDotenv = "dotenv"
+	// ProviderEnv reads the value from the process environment
+	ProviderEnv = "env"
+	// ProviderFile reads the value from a file, byte for byte
+	ProviderFile = "file"
+	// ProviderCommand reads the value from the stdout of a local command
+	ProviderCommand = "command"
+)
+
+// SecretConfig describes a secret and where its value is read from. Without
+// a provider, the value is read from the encrypted secrets file, then the
+// env file, then the environment.
+type SecretConfig struct {
+	Name     string `yaml:"name" desc:"Secret name"`
+	Provider string `yaml:"provider" desc:"Where the value is read from; defaults to the encrypted secrets file, then the env file, then the environment" enum:"encrypted,dotenv,env,file,command"`
+	Key      string `yaml:"key" desc:"Variable holding the value for the encrypted, dotenv and env providers; defaults to name"`
+	File     string `yaml:"file" desc:"File holding the value for the file provider, relative to swarm.yaml"`
+	Command  string `yaml:"command" desc:"Local command printing the value for the command provider"`
+}
+
+// UnmarshalYAML accepts both a plain secret name and a mapping
+func (s *SecretConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*s = SecretConfig{Name: value.Value}
+		return nil
+	}
+
+	// Decode through a type without this method to avoid recursion
+	type plain SecretConfig
+	return value.Decode((*plain)(s))
+}
+
+// JSONSchema describes the accepted shapes of a secret entry
+func (s SecretConfig) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			structSchema(reflect.TypeOf(s)),
+		},
+	}
+}
+
+// LookupKey returns the variable holding the value for the encrypted,
+// dotenv and env providers
+func (s SecretConfig) LookupKey() string {
+	if s.Key != "" {
+		return s.Key
+	}
+	return s.Name
+}
+
+// validate checks the provider settings of a secret
+func (s SecretConfig) validate(ve *ValidationError) {
+	switch s.Provider {
+	case "", ProviderEncrypted, ProviderDotenv, ProviderEnv:
+	case ProviderFile:
+		if s.File == "" {
+			ve.Add(fmt.Sprintf("secret %s: file is required with the file provider", s.Name))
+		} else if _, err := os.Stat(s.File); os.IsNotExist(err) {
+			ve.Add(fmt.Sprintf("secret %s: file not found: %s", s.Name, s.File))
+		}
+	case ProviderCommand:
+		if s.Command == "" {
+			ve.Add(fmt.Sprintf("secret %s: command is required with the command provider", s.Name))
+		}
+	default:
+		ve.Add(fmt.Sprintf("secret %s: invalid provider '%s': must be encrypted, dotenv, env, file or command", s.Name, s.Provider))
+	}
+}
+
+// SecretList is the list of secrets in swarm.yaml
+type SecretList []SecretConfig
+
+// Names returns the names of the secrets
+func (l SecretList) Names() []string {
+	names := make([]string, len(l))
+	for i, s := range l {
+		names[i] = s.Name
+	}
+	return names
+}
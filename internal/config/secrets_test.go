@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_SecretEntries(t *testing.T) {
+	path := writeConfig(t, `stack: myapp
+secrets:
+  - DATABASE_URL
+  - name: API_KEY
+    provider: command
+    command: pass show myapp/api_key
+  - name: TLS_KEY
+    provider: file
+    file: certs/tls.key
+  - name: SENTRY_DSN
+    provider: env
+    key: CI_SENTRY_DSN
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if got := strings.Join(cfg.Secrets.Names(), ","); got != "DATABASE_URL,API_KEY,TLS_KEY,SENTRY_DSN" {
+		t.Errorf("unexpected secret names: %s", got)
+	}
+	if s := cfg.Secrets[0]; s.Provider != "" || s.LookupKey() != "DATABASE_URL" {
+		t.Errorf("plain entry = %+v", s)
+	}
+	if s := cfg.Secrets[1]; s.Provider != ProviderCommand || s.Command != "pass show myapp/api_key" {
+		t.Errorf("command entry = %+v", s)
+	}
+	if s := cfg.Secrets[2]; s.File != filepath.Join(filepath.Dir(path), "certs/tls.key") {
+		t.Errorf("file should resolve relative to swarm.yaml, got %s", s.File)
+	}
+	if s := cfg.Secrets[3]; s.LookupKey() != "CI_SENTRY_DSN" {
+		t.Errorf("expected lookup key CI_SENTRY_DSN, got %s", s.LookupKey())
+	}
+}
+
+func TestLoad_SecretEntryUnknownKey(t *testing.T) {
+	path := writeConfig(t, `stack: myapp
+secrets:
+  - name: API_KEY
+    provder: command
+`)
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), `unknown key "provder" in secrets[0]`) {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestValidateSecrets(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "tls.key")
+	if err := os.WriteFile(keyFile, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		secrets SecretList
+		wantErr string
+	}{
+		{"valid", SecretList{{Name: "A"}, {Name: "B", Provider: ProviderFile, File: keyFile}}, ""},
+		{"missing name", SecretList{{Provider: ProviderEnv}}, "secret name is required"},
+		{"duplicate", SecretList{{Name: "API_KEY"}, {Name: "api_key"}}, "duplicate secret 'api_key'"},
+		{"unknown provider", SecretList{{Name: "A", Provider: "vault"}}, "invalid provider 'vault'"},
+		{"command without command", SecretList{{Name: "A", Provider: ProviderCommand}}, "command is required"},
+		{"file without file", SecretList{{Name: "A", Provider: ProviderFile}}, "file is required"},
+		{"missing file", SecretList{{Name: "A", Provider: ProviderFile, File: "/nonexistent/key"}}, "file not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.Stack = "myapp"
+			cfg.ComposeFile = nil
+			cfg.Secrets = tt.secrets
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSchema_SecretEntries(t *testing.T) {
+	data, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+
+	var schema struct {
+		Properties struct {
+			Secrets struct {
+				Items struct {
+					OneOf []map[string]interface{} `json:"oneOf"`
+				} `json:"items"`
+			} `json:"secrets"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	oneOf := schema.Properties.Secrets.Items.OneOf
+	if len(oneOf) != 2 || oneOf[0]["type"] != "string" || oneOf[1]["type"] != "object" {
+		t.Fatalf("expected string or object secret entries, got %v", oneOf)
+	}
+	props, _ := oneOf[1]["properties"].(map[string]interface{})
+	for _, key := range []string{"name", "provider", "key", "file", "command"} {
+		if _, ok := props[key]; !ok {
+			t.Errorf("secret entry schema missing %q", key)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marcelsud/swarmctl/internal/sshconfig"
+)
+
+// applySSHConfig fills in the ssh settings swarm.yaml leaves unset from
+// the entries of ~/.ssh/config matching ssh.host, so ssh.host can be a
+// Host alias. Jump hosts are looked up the same way.
+func (d *Document) applySSHConfig(cfg *Config) error {
+	if cfg.SSH.Host == "" {
+		return nil
+	}
+
+	userConfig, err := sshconfig.LoadUser()
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", sshconfig.UserConfigPath(), err)
+	}
+
+	host := userConfig.Lookup(cfg.SSH.Host)
+	cfg.SSH.Host = host.HostName
+	if cfg.SSH.User == "" {
+		cfg.SSH.User = host.User
+	}
+	// The port always has a value, so only its key tells whether it is set
+	if d.Origin("ssh.port") == "" && host.Port != 0 {
+		cfg.SSH.Port = host.Port
+	}
+	if cfg.SSH.Key == "" {
+		cfg.SSH.Key = firstExisting(host.IdentityFiles)
+	}
+	if len(cfg.SSH.ProxyJump) == 0 && host.ProxyJump != "" {
+		for _, s := range strings.Split(host.ProxyJump, ",") {
+			hop, err := ParseJumpHost(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("%s: ProxyJump for %s: %w", sshconfig.UserConfigPath(), cfg.SSH.Host, err)
+			}
+			cfg.SSH.ProxyJump = append(cfg.SSH.ProxyJump, hop)
+		}
+	}
+	cfg.SSH.KnownHostsFiles = host.UserKnownHostsFiles
+	cfg.SSH.StrictHostKeyChecking = host.StrictHostKeyChecking
+
+	for i, hop := range cfg.SSH.ProxyJump {
+		jump := userConfig.Lookup(hop.Host)
+		hop.Host = jump.HostName
+		if hop.User == "" {
+			hop.User = jump.User
+		}
+		if hop.Port == 0 {
+			hop.Port = jump.Port
+		}
+		if hop.Key == "" {
+			hop.Key = firstExisting(jump.IdentityFiles)
+		}
+		cfg.SSH.ProxyJump[i] = hop
+	}
+	return nil
+}
+
+// firstExisting returns the first of paths that exists, or ""
+func firstExisting(paths []string) string {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeSSHConfig points HOME at a temporary directory with the given
+// ~/.ssh/config and returns the directory
+func writeSSHConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "config"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func TestLoad_SSHConfig(t *testing.T) {
+	home := writeSSHConfig(t, `Host prod-manager
+    HostName 10.0.0.10
+    User deploy
+    Port 2222
+    IdentityFile ~/.ssh/missing
+    IdentityFile ~/.ssh/prod_ed25519
+    ProxyJump bastion
+    UserKnownHostsFile ~/.ssh/known_hosts_prod
+    StrictHostKeyChecking yes
+
+Host bastion
+    HostName bastion.example.com
+    User admin
+`)
+	key := filepath.Join(home, ".ssh", "prod_ed25519")
+	if err := os.WriteFile(key, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(writeConfig(t, "stack: myapp\nssh:\n  host: prod-manager\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	want := SSHConfig{
+		Host:                  "10.0.0.10",
+		User:                  "deploy",
+		Port:                  2222,
+		Key:                   key,
+		ProxyJump:             JumpHosts{{Host: "bastion.example.com", User: "admin"}},
+		KnownHostsFiles:       []string{filepath.Join(home, ".ssh", "known_hosts_prod")},
+		StrictHostKeyChecking: "yes",
+	}
+	if !reflect.DeepEqual(cfg.SSH, want) {
+		t.Errorf("SSH = %+v, want %+v", cfg.SSH, want)
+	}
+	if err := cfg.Validate(); err != nil && strings.Contains(err.Error(), "ssh.user") {
+		t.Errorf("expected ssh.user from ~/.ssh/config to pass validation, got %v", err)
+	}
+}
+
+func TestLoad_SSHConfigExplicitValuesWin(t *testing.T) {
+	writeSSHConfig(t, `Host prod-manager
+    HostName 10.0.0.10
+    User deploy
+    Port 2222
+    ProxyJump bastion
+
+Host *
+    User nobody
+    Port 2200
+`)
+
+	cfg, err := Load(writeConfig(t, `stack: myapp
+ssh:
+  host: prod-manager
+  user: root
+  port: 22
+  proxy_jump:
+    host: edge.example.com
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.SSH.Host != "10.0.0.10" {
+		t.Errorf("expected host resolved to 10.0.0.10, got %s", cfg.SSH.Host)
+	}
+	if cfg.SSH.User != "root" || cfg.SSH.Port != 22 {
+		t.Errorf("expected root on port 22 from swarm.yaml, got %s on %d", cfg.SSH.User, cfg.SSH.Port)
+	}
+	// Jump hosts are looked up too, with their own values winning
+	want := JumpHosts{{Host: "edge.example.com", User: "nobody", Port: 2200}}
+	if !reflect.DeepEqual(cfg.SSH.ProxyJump, want) {
+		t.Errorf("expected proxy_jump %+v, got %+v", want, cfg.SSH.ProxyJump)
+	}
+}
+
+func TestLoad_SSHConfigNoMatch(t *testing.T) {
+	writeSSHConfig(t, "Host other\n    User deploy\n    Port 2222\n")
+
+	cfg, err := Load(writeConfig(t, "stack: myapp\nssh:\n  host: example.com\n  user: root\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	want := SSHConfig{Host: "example.com", User: "root", Port: 22}
+	if !reflect.DeepEqual(cfg.SSH, want) {
+		t.Errorf("SSH = %+v, want %+v", cfg.SSH, want)
+	}
+}
+
+func TestLoad_SSHConfigInvalid(t *testing.T) {
+	writeSSHConfig(t, "Host prod-manager\n    Port ssh\n")
+
+	_, err := Load(writeConfig(t, "stack: myapp\nssh:\n  host: prod-manager\n"))
+	if err == nil || !strings.Contains(err.Error(), "invalid port 'ssh'") {
+		t.Errorf("expected invalid port error from ~/.ssh/config, got %v", err)
+	}
+
+	// Local mode does not read ~/.ssh/config
+	if _, err := Load(writeConfig(t, "stack: myapp\n")); err != nil {
+		t.Errorf("expected local mode to ignore ~/.ssh/config, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// extensionPrefix marks top-level keys that are ignored by swarmctl
+const extensionPrefix = "x-"
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// checkKeys reports every mapping key under node that has no matching
+// field in t, with the file, line and column where it appears
+func checkKeys(node *yaml.Node, t reflect.Type, path, file string, ve *ValidationError) {
+	node = resolveAlias(node)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Types with custom decoding accept their own shapes; structs written
+	// as a mapping and lists written as a sequence are still checked field
+	// by field, and a single item written in place of a list as the item
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		switch {
+		case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		case t.Kind() == reflect.Slice && node.Kind == yaml.MappingNode:
+			checkKeys(node, t.Elem(), path, file, ve)
+			return
+		default:
+			return
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Tag == "!!merge" {
+				checkKeys(value, t, path, file, ve)
+				continue
+			}
+
+			// Top-level x- keys hold YAML anchors, as in compose files
+			if path == "" && strings.HasPrefix(key.Value, extensionPrefix) {
+				continue
+			}
+
+			field, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("%s:%d:%d: unknown key %q", file, key.Line, key.Column, key.Value)
+				if path != "" {
+					msg += " in " + path
+				}
+				ve.Add(msg)
+				continue
+			}
+			checkKeys(value, field.Type, joinPath(path, key.Value), file, ve)
+		}
+
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkKeys(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), file, ve)
+		}
+
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			checkKeys(item, t.Elem(), indexPath(path, i), file, ve)
+		}
+	}
+}
+
+// yamlFields maps the YAML key of each exported field of t to the field
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := yamlName(f)
+		if name == "-" {
+			continue
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// yamlName returns the key a struct field is decoded from
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoad_UnknownKeys(t *testing.T) {
+	swarmPath := writeConfig(t, `stack: myapp
+acessories:
+  - redis
+ssh:
+  host: example.com
+  prot: 22
+nodes:
+  worker-1:
+    usr: root
+`)
+
+	_, err := Load(swarmPath)
+	if err == nil {
+		t.Fatal("expected error for unknown keys")
+	}
+
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+
+	want := []string{
+		`swarm.yaml:2:1: unknown key "acessories"`,
+		`swarm.yaml:6:3: unknown key "prot" in ssh`,
+		`swarm.yaml:9:5: unknown key "usr" in nodes.worker-1`,
+	}
+	if len(ve.Errors) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), ve.Errors)
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(ve.Errors[i], w) {
+			t.Errorf("error %d = %q, want suffix %q", i, ve.Errors[i], w)
+		}
+	}
+}
+
+func TestLoadDestination_UnknownKeysInDestination(t *testing.T) {
+	swarmPath := writeDestinationFixture(t, baseConfig, `
+ssh:
+  hots: staging.example.com
+`)
+
+	_, err := LoadDestination(swarmPath, "staging")
+	if err == nil {
+		t.Fatal("expected error for unknown key in destination file")
+	}
+	if !strings.Contains(err.Error(), `swarm.staging.yaml:3:3: unknown key "hots" in ssh`) {
+		t.Errorf("error should point at the destination file: %v", err)
+	}
+}
+
+func TestLoad_ExtensionKeysWithAnchors(t *testing.T) {
+	swarmPath := writeConfig(t, `
+x-user: &user
+  user: deploy
+stack: myapp
+ssh:
+  <<: *user
+  host: example.com
+`)
+
+	cfg, err := Load(swarmPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.SSH.User != "deploy" {
+		t.Errorf("expected SSH user from anchor, got '%s'", cfg.SSH.User)
+	}
+}
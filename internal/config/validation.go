@@ -3,9 +3,14 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 )
 
+// validConfigName matches names accepted for Docker configs
+var validConfigName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,63}$`)
+
 // ValidationError holds multiple validation errors
 type ValidationError struct {
 	Errors []string
@@ -40,7 +45,7 @@ func (c *Config) Validate() error {
 	// SSH is optional - if host is provided, user is required
 	if c.SSH.Host != "" {
 		if c.SSH.User == "" {
-			ve.Add("ssh.user is required when ssh.host is set")
+			ve.Add("ssh.user is required when ssh.host is set (in swarm.yaml or ~/.ssh/config)")
 		}
 
 		if c.SSH.Port <= 0 || c.SSH.Port > 65535 {
@@ -53,12 +58,80 @@ func (c *Config) Validate() error {
 				ve.Add(fmt.Sprintf("SSH key file not found: %s", c.SSH.Key))
 			}
 		}
+
+		for i, hop := range c.SSH.ProxyJump {
+			if hop.Host == "" {
+				ve.Add(fmt.Sprintf("ssh.proxy_jump[%d]: host is required", i))
+			}
+			if hop.Port < 0 || hop.Port > 65535 {
+				ve.Add(fmt.Sprintf("ssh.proxy_jump[%d]: port must be between 1 and 65535", i))
+			}
+			if hop.Key != "" {
+				if _, err := os.Stat(hop.Key); os.IsNotExist(err) {
+					ve.Add(fmt.Sprintf("ssh.proxy_jump[%d]: SSH key file not found: %s", i, hop.Key))
+				}
+			}
+		}
+	} else if len(c.SSH.ProxyJump) > 0 {
+		ve.Add("ssh.proxy_jump requires ssh.host")
+	}
+
+	// Check if compose files exist
+	for _, file := range c.ComposeFile {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			ve.Add(fmt.Sprintf("compose file not found: %s", file))
+		}
+	}
+
+	// An env_file that is set must exist, instead of silently falling back
+	// to the environment
+	if c.EnvFile != "" {
+		if _, err := os.Stat(c.EnvFile); os.IsNotExist(err) {
+			ve.Add(fmt.Sprintf("env file not found: %s", c.EnvFile))
+		}
 	}
 
-	// Check if compose file exists
-	if c.ComposeFile != "" {
-		if _, err := os.Stat(c.ComposeFile); os.IsNotExist(err) {
-			ve.Add(fmt.Sprintf("compose file not found: %s", c.ComposeFile))
+	// Check secrets
+	seen := make(map[string]bool, len(c.Secrets))
+	for _, secret := range c.Secrets {
+		if secret.Name == "" {
+			ve.Add("secret name is required")
+			continue
+		}
+		if seen[strings.ToLower(secret.Name)] {
+			ve.Add(fmt.Sprintf("duplicate secret '%s'", secret.Name))
+		}
+		seen[strings.ToLower(secret.Name)] = true
+		secret.validate(ve)
+	}
+
+	// Check that services only attach secrets listed in secrets
+	services := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		for _, name := range c.Services[service].Secrets {
+			if !seen[strings.ToLower(name)] {
+				ve.Add(fmt.Sprintf("services.%s.secrets: secret '%s' is not listed in secrets", service, name))
+			}
+		}
+	}
+
+	// Check configs
+	names := make([]string, 0, len(c.Configs))
+	for name := range c.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file := c.Configs[name]
+		if !validConfigName.MatchString(name) {
+			ve.Add(fmt.Sprintf("invalid config name '%s': use letters, digits, '_', '-' and '.'", name))
+		}
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			ve.Add(fmt.Sprintf("config file not found for %s: %s", name, file))
 		}
 	}
 
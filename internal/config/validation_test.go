@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -76,7 +77,7 @@ func TestValidateSSHUserRequiredWhenHostSet(t *testing.T) {
 			Host: "example.com",
 			Port: 22,
 		},
-		ComposeFile: composePath,
+		ComposeFile: ComposeFiles{composePath},
 	}
 
 	err := cfg.Validate()
@@ -112,7 +113,7 @@ func TestValidateLocalModeNoSSH(t *testing.T) {
 	// No SSH config - should be valid for local mode
 	cfg := &Config{
 		Stack:       "myapp",
-		ComposeFile: composePath,
+		ComposeFile: ComposeFiles{composePath},
 	}
 
 	err := cfg.Validate()
@@ -137,7 +138,7 @@ func TestValidateValidConfig(t *testing.T) {
 			User: "deploy",
 			Port: 22,
 		},
-		ComposeFile: composePath,
+		ComposeFile: ComposeFiles{composePath},
 	}
 
 	err := cfg.Validate()
@@ -174,7 +175,7 @@ func TestValidateInvalidPort(t *testing.T) {
 				User: "deploy",
 				Port: tt.port,
 			},
-			ComposeFile: composePath,
+			ComposeFile: ComposeFiles{composePath},
 		}
 
 		err := cfg.Validate()
@@ -194,7 +195,7 @@ func TestValidateComposeFileNotFound(t *testing.T) {
 			User: "deploy",
 			Port: 22,
 		},
-		ComposeFile: "/nonexistent/docker-compose.yaml",
+		ComposeFile: ComposeFiles{"/nonexistent/docker-compose.yaml"},
 	}
 
 	err := cfg.Validate()
@@ -235,7 +236,7 @@ func TestValidateSSHKeyNotFound(t *testing.T) {
 			Port: 22,
 			Key:  "/nonexistent/id_rsa",
 		},
-		ComposeFile: composePath,
+		ComposeFile: ComposeFiles{composePath},
 	}
 
 	err := cfg.Validate()
@@ -283,7 +284,7 @@ func TestValidateWithExistingSSHKey(t *testing.T) {
 			Port: 22,
 			Key:  keyPath,
 		},
-		ComposeFile: composePath,
+		ComposeFile: ComposeFiles{composePath},
 	}
 
 	err := cfg.Validate()
@@ -303,7 +304,7 @@ func TestValidateModeSwarm(t *testing.T) {
 	cfg := &Config{
 		Stack:       "myapp",
 		Mode:        ModeSwarm,
-		ComposeFile: composePath,
+		ComposeFile: ComposeFiles{composePath},
 	}
 
 	err := cfg.Validate()
@@ -323,7 +324,7 @@ func TestValidateModeCompose(t *testing.T) {
 	cfg := &Config{
 		Stack:       "myapp",
 		Mode:        ModeCompose,
-		ComposeFile: composePath,
+		ComposeFile: ComposeFiles{composePath},
 	}
 
 	err := cfg.Validate()
@@ -343,7 +344,7 @@ func TestValidateModeInvalid(t *testing.T) {
 	cfg := &Config{
 		Stack:       "myapp",
 		Mode:        "kubernetes", // invalid mode
-		ComposeFile: composePath,
+		ComposeFile: ComposeFiles{composePath},
 	}
 
 	err := cfg.Validate()
@@ -380,7 +381,7 @@ func TestValidateModeEmpty(t *testing.T) {
 	cfg := &Config{
 		Stack:       "myapp",
 		Mode:        "",
-		ComposeFile: composePath,
+		ComposeFile: ComposeFiles{composePath},
 	}
 
 	err := cfg.Validate()
@@ -388,3 +389,116 @@ func TestValidateModeEmpty(t *testing.T) {
 		t.Errorf("expected no validation error for empty mode, got: %v", err)
 	}
 }
+
+func TestValidateConfigs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	composePath := filepath.Join(tmpDir, "docker-compose.yaml")
+	nginxPath := filepath.Join(tmpDir, "nginx.conf")
+	for _, path := range []string{composePath, nginxPath} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &Config{
+		Stack:       "myapp",
+		ComposeFile: ComposeFiles{composePath},
+		Configs: map[string]string{
+			"nginx_conf": nginxPath,
+			"app.yaml":   filepath.Join(tmpDir, "missing.yaml"),
+			"bad name":   nginxPath,
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	ve := err.(*ValidationError)
+	if len(ve.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %v", ve.Errors)
+	}
+	if !strings.Contains(ve.Errors[0], "config file not found for app.yaml") {
+		t.Errorf("unexpected error: %s", ve.Errors[0])
+	}
+	if !strings.Contains(ve.Errors[1], "invalid config name 'bad name'") {
+		t.Errorf("unexpected error: %s", ve.Errors[1])
+	}
+}
+
+func TestValidateServiceSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	composePath := filepath.Join(tmpDir, "docker-compose.yaml")
+	if err := os.WriteFile(composePath, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{
+		Stack:       "myapp",
+		ComposeFile: ComposeFiles{composePath},
+		Secrets:     SecretList{{Name: "DATABASE_URL"}, {Name: "API_KEY"}},
+		Services: map[string]ServiceConfig{
+			"web":    {Secrets: []string{"DATABASE_URL", "api_key"}},
+			"worker": {Secrets: []string{"DATABASE_URL", "SMTP_PASSWORD"}},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	ve := err.(*ValidationError)
+	if len(ve.Errors) != 1 || !strings.Contains(ve.Errors[0], "services.worker.secrets: secret 'SMTP_PASSWORD' is not listed") {
+		t.Errorf("unexpected errors: %v", ve.Errors)
+	}
+}
+
+func TestValidateProxyJump(t *testing.T) {
+	cfg := &Config{
+		Stack: "myapp",
+		SSH: SSHConfig{
+			Host: "example.com",
+			User: "deploy",
+			Port: 22,
+			ProxyJump: JumpHosts{
+				{Host: "bastion.example.com"},
+				{Port: 70000, Key: "/nonexistent/bastion"},
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	ve, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+	want := []string{
+		"ssh.proxy_jump[1]: host is required",
+		"ssh.proxy_jump[1]: port must be between 1 and 65535",
+		"ssh.proxy_jump[1]: SSH key file not found: /nonexistent/bastion",
+	}
+	if !reflect.DeepEqual(ve.Errors, want) {
+		t.Errorf("expected errors %v, got %v", want, ve.Errors)
+	}
+
+	cfg.SSH = SSHConfig{ProxyJump: JumpHosts{{Host: "bastion.example.com"}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "ssh.proxy_jump requires ssh.host") {
+		t.Errorf("expected proxy_jump without host error, got %v", err)
+	}
+}
+
+func TestValidateEnvFileNotFound(t *testing.T) {
+	cfg := &Config{
+		Stack:   "myapp",
+		EnvFile: filepath.Join(t.TempDir(), ".env.prodution"),
+	}
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "env file not found") {
+		t.Errorf("expected 'env file not found' error, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package configs
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/marcelsud/swarmctl/internal/compose"
+	"github.com/marcelsud/swarmctl/internal/executor"
+	"github.com/marcelsud/swarmctl/internal/versioned"
+)
+
+// Manager handles versioned Docker Swarm configs
+type Manager struct {
+	exec  executor.Executor
+	store *versioned.Store
+}
+
+// NewManager creates a new configs manager
+func NewManager(exec executor.Executor, stackName string) *Manager {
+	return &Manager{
+		exec:  exec,
+		store: versioned.NewStore(exec, stackName, versioned.Config),
+	}
+}
+
+// Config represents a config with its name and content
+type Config struct {
+	Name    string
+	Content []byte
+}
+
+// Load reads the files of the configs defined in swarm.yaml, sorted by name
+func Load(files map[string]string) ([]Config, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	configs := make([]Config, 0, len(names))
+	for _, name := range names {
+		content, err := os.ReadFile(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config %s: %w", name, err)
+		}
+		configs = append(configs, Config{Name: name, Content: content})
+	}
+	return configs, nil
+}
+
+// VersionedName returns the Docker config name for a version of a config:
+// <stack>_<name>_<hash>
+func (m *Manager) VersionedName(name string, content []byte) string {
+	return m.store.Name(name, content)
+}
+
+// Create creates the config version for content unless it already exists.
+// It returns the versioned name and whether a new version was created.
+func (m *Manager) Create(name string, content []byte) (string, bool, error) {
+	versionedName := m.store.Name(name, content)
+
+	exists, err := m.store.Exists(name, versionedName)
+	if err != nil {
+		return "", false, err
+	}
+	if exists {
+		return versionedName, false, nil
+	}
+
+	// Upload to a temp file, docker config create reads it from there
+	tmpPath := fmt.Sprintf("/tmp/%s", versionedName)
+	if err := m.exec.WriteFile(tmpPath, content); err != nil {
+		return "", false, fmt.Errorf("failed to upload config: %w", err)
+	}
+	defer m.exec.Run(fmt.Sprintf("rm -f %s", shellquote.Join(tmpPath)))
+
+	cmd := fmt.Sprintf("docker config create %s %s", shellquote.Join(versionedName), shellquote.Join(tmpPath))
+	result, err := m.exec.Run(cmd)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create config: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return "", false, fmt.Errorf("config creation failed: %s", result.Stderr)
+	}
+
+	return versionedName, true, nil
+}
+
+// Prune removes the versions of a config other than current and the
+// previous one, kept for rollback, that no service uses anymore, and
+// returns the removed versions
+func (m *Manager) Prune(name, current string) ([]string, error) {
+	return m.store.Prune(name, current)
+}
+
+// UseVersions points the top-level compose configs at the given versioned
+// Docker configs, so services keep referencing them by their short name
+func UseVersions(composeContent []byte, versions map[string]string) ([]byte, error) {
+	return setConfigs(composeContent, versions, func(versioned string) *compose.Resource {
+		return &compose.Resource{Name: versioned, External: true}
+	})
+}
+
+// UseFiles points the top-level compose configs at local files. Used in
+// compose mode, where docker compose mounts config files directly.
+func UseFiles(composeContent []byte, files map[string]string) ([]byte, error) {
+	return setConfigs(composeContent, files, func(file string) *compose.Resource {
+		return &compose.Resource{File: file}
+	})
+}
+
+func setConfigs(composeContent []byte, values map[string]string, resource func(string) *compose.Resource) ([]byte, error) {
+	if len(values) == 0 {
+		return composeContent, nil
+	}
+
+	project, err := compose.Parse(composeContent)
+	if err != nil {
+		return nil, err
+	}
+	if project.Configs == nil {
+		project.Configs = make(map[string]*compose.Resource)
+	}
+	for name, value := range values {
+		project.Configs[name] = resource(value)
+	}
+	return project.Marshal()
+}
@@ -0,0 +1,274 @@
+package configs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marcelsud/swarmctl/internal/compose"
+	"github.com/marcelsud/swarmctl/internal/executor"
+	"github.com/marcelsud/swarmctl/internal/versioned"
+)
+
+// ConfigsMockExecutor for testing configs
+type ConfigsMockExecutor struct {
+	runCommands []string
+	runResults  map[string]*executor.CommandResult
+	writeFiles  map[string][]byte
+}
+
+func NewConfigsMockExecutor() *ConfigsMockExecutor {
+	return &ConfigsMockExecutor{
+		runCommands: make([]string, 0),
+		runResults:  make(map[string]*executor.CommandResult),
+		writeFiles:  make(map[string][]byte),
+	}
+}
+
+func (m *ConfigsMockExecutor) Run(cmd string) (*executor.CommandResult, error) {
+	m.runCommands = append(m.runCommands, cmd)
+
+	if result, exists := m.runResults[cmd]; exists {
+		return result, nil
+	}
+
+	// Default successful result
+	return &executor.CommandResult{ExitCode: 0}, nil
+}
+
+func (m *ConfigsMockExecutor) RunInteractive(cmd string) error {
+	m.runCommands = append(m.runCommands, cmd)
+	return nil
+}
+
+func (m *ConfigsMockExecutor) RunStream(cmd string, stdout, stderr io.Writer) error {
+	m.runCommands = append(m.runCommands, cmd)
+	return nil
+}
+
+func (m *ConfigsMockExecutor) RunContext(ctx context.Context, cmd string) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
+func (m *ConfigsMockExecutor) RunInteractiveContext(ctx context.Context, cmd string) error {
+	return m.RunInteractive(cmd)
+}
+
+func (m *ConfigsMockExecutor) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	return m.RunStream(cmd, stdout, stderr)
+}
+
+func (m *ConfigsMockExecutor) RunWithStdin(cmd string, stdin io.Reader) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
+func (m *ConfigsMockExecutor) WriteFile(path string, content []byte) error {
+	m.writeFiles[path] = content
+	return nil
+}
+
+func (m *ConfigsMockExecutor) Close() error {
+	return nil
+}
+
+func (m *ConfigsMockExecutor) IsLocal() bool {
+	return false
+}
+
+func (m *ConfigsMockExecutor) SetVerbose(v bool) {
+	// Mock implementation - just store the value
+}
+
+func (m *ConfigsMockExecutor) SetRunResult(cmd string, result *executor.CommandResult) {
+	m.runResults[cmd] = result
+}
+
+func hasCommand(commands []string, cmd string) bool {
+	for _, c := range commands {
+		if c == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+const listNginxCmd = "docker config ls --filter name=myapp_nginx_conf_ --format '{{.Name}}'"
+
+func TestVersionedName(t *testing.T) {
+	m := NewManager(nil, "myapp")
+
+	v1 := m.VersionedName("Nginx_Conf", []byte("server {}"))
+	v2 := m.VersionedName("Nginx_Conf", []byte("server { listen 80; }"))
+
+	if !strings.HasPrefix(v1, "myapp_nginx_conf_") || len(v1) != len("myapp_nginx_conf_")+versioned.HashLength {
+		t.Errorf("unexpected versioned name: %s", v1)
+	}
+	if v1 == v2 {
+		t.Error("different content should produce different versions")
+	}
+	if v1 != m.VersionedName("nginx_conf", []byte("server {}")) {
+		t.Error("same content should produce the same version")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	mockExec := NewConfigsMockExecutor()
+	m := NewManager(mockExec, "myapp")
+	content := []byte("server {}")
+	versioned := m.VersionedName("nginx_conf", content)
+
+	name, created, err := m.Create("nginx_conf", content)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if name != versioned || !created {
+		t.Errorf("Create() = %s, %v; want %s, true", name, created, versioned)
+	}
+
+	tmpPath := "/tmp/" + versioned
+	if string(mockExec.writeFiles[tmpPath]) != "server {}" {
+		t.Errorf("expected content uploaded to %s", tmpPath)
+	}
+	if !hasCommand(mockExec.runCommands, "docker config create "+versioned+" "+tmpPath) {
+		t.Errorf("expected docker config create, got %v", mockExec.runCommands)
+	}
+	if !hasCommand(mockExec.runCommands, "rm -f "+tmpPath) {
+		t.Errorf("expected temp file cleanup, got %v", mockExec.runCommands)
+	}
+}
+
+func TestCreate_Existing(t *testing.T) {
+	mockExec := NewConfigsMockExecutor()
+	m := NewManager(mockExec, "myapp")
+	content := []byte("server {}")
+	versioned := m.VersionedName("nginx_conf", content)
+
+	mockExec.SetRunResult(listNginxCmd, &executor.CommandResult{Stdout: versioned + "\n"})
+
+	name, created, err := m.Create("nginx_conf", content)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if name != versioned || created {
+		t.Errorf("Create() = %s, %v; want %s, false", name, created, versioned)
+	}
+	if len(mockExec.writeFiles) != 0 {
+		t.Error("existing version should not be uploaded again")
+	}
+}
+
+func TestCreate_Failure(t *testing.T) {
+	mockExec := NewConfigsMockExecutor()
+	m := NewManager(mockExec, "myapp")
+	content := []byte("server {}")
+	versioned := m.VersionedName("nginx_conf", content)
+
+	mockExec.SetRunResult("docker config create "+versioned+" /tmp/"+versioned, &executor.CommandResult{ExitCode: 1, Stderr: "boom"})
+
+	if _, _, err := m.Create("nginx_conf", content); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected creation error, got %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	mockExec := NewConfigsMockExecutor()
+	m := NewManager(mockExec, "myapp")
+
+	current := "myapp_nginx_conf_aaaaaaaaaaaa"
+	old := "myapp_nginx_conf_bbbbbbbbbbbb"
+	inUse := "myapp_nginx_conf_cccccccccccc"
+	previous := "myapp_nginx_conf_dddddddddddd"
+	mockExec.SetRunResult(listNginxCmd, &executor.CommandResult{
+		Stdout: strings.Join([]string{current, old, inUse, previous, "myapp_nginx_conf_extra_aaaaaaaaaaaa"}, "\n"),
+	})
+	mockExec.SetRunResult("docker config inspect --format '{{.CreatedAt.UnixNano}} {{.Spec.Name}}' "+strings.Join([]string{old, inUse, previous}, " "), &executor.CommandResult{
+		Stdout: "100 " + old + "\n200 " + inUse + "\n300 " + previous + "\n",
+	})
+	mockExec.SetRunResult("docker config rm "+inUse, &executor.CommandResult{ExitCode: 1, Stderr: "config is in use"})
+
+	removed, err := m.Prune("nginx_conf", current)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != old {
+		t.Errorf("expected only %s to be removed, got %v", old, removed)
+	}
+	if hasCommand(mockExec.runCommands, "docker config rm "+current) {
+		t.Error("current version should not be removed")
+	}
+	if hasCommand(mockExec.runCommands, "docker config rm "+previous) {
+		t.Error("previous version should be kept for rollback")
+	}
+	if hasCommand(mockExec.runCommands, "docker config rm myapp_nginx_conf_extra_aaaaaaaaaaaa") {
+		t.Error("configs that only share the prefix should not be touched")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tmpDir := t.TempDir()
+	nginxPath := filepath.Join(tmpDir, "nginx.conf")
+	if err := os.WriteFile(nginxPath, []byte("server {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configs, err := Load(map[string]string{"nginx_conf": nginxPath})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(configs) != 1 || configs[0].Name != "nginx_conf" || string(configs[0].Content) != "server {}" {
+		t.Errorf("unexpected configs: %+v", configs)
+	}
+
+	if _, err := Load(map[string]string{"missing": filepath.Join(tmpDir, "missing")}); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+const configsCompose = `
+services:
+  web:
+    image: nginx
+    configs:
+      - source: nginx_conf
+        target: /etc/nginx/nginx.conf
+configs:
+  nginx_conf:
+    file: ./nginx.conf
+`
+
+func TestUseVersions(t *testing.T) {
+	out, err := UseVersions([]byte(configsCompose), map[string]string{"nginx_conf": "myapp_nginx_conf_aaaaaaaaaaaa"})
+	if err != nil {
+		t.Fatalf("UseVersions failed: %v", err)
+	}
+
+	p, err := compose.Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := p.Configs["nginx_conf"]
+	if !c.External || c.Name != "myapp_nginx_conf_aaaaaaaaaaaa" || c.File != "" {
+		t.Errorf("unexpected config definition: %+v", c)
+	}
+	if p.Services["web"].Configs[0].Source != "nginx_conf" {
+		t.Error("service references should keep the short name")
+	}
+}
+
+func TestUseFiles(t *testing.T) {
+	out, err := UseFiles([]byte(configsCompose), map[string]string{"nginx_conf": "/abs/nginx.conf"})
+	if err != nil {
+		t.Fatalf("UseFiles failed: %v", err)
+	}
+
+	p, err := compose.Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := p.Configs["nginx_conf"]; c.File != "/abs/nginx.conf" || c.External {
+		t.Errorf("unexpected config definition: %+v", c)
+	}
+}
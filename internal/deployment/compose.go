@@ -1,14 +1,18 @@
 package deployment
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"path"
 	"strings"
 	"time"
 
+	"github.com/marcelsud/swarmctl/internal/compose"
 	"github.com/marcelsud/swarmctl/internal/executor"
 	"github.com/marcelsud/swarmctl/internal/history"
+	"github.com/marcelsud/swarmctl/internal/hostdir"
 )
 
 // ComposeManager implements Manager for docker compose deployments
@@ -16,6 +20,7 @@ type ComposeManager struct {
 	exec        executor.Executor
 	projectName string
 	history     *history.Manager
+	accessories []string
 }
 
 // NewComposeManager creates a new ComposeManager
@@ -27,6 +32,13 @@ func NewComposeManager(exec executor.Executor, projectName string) *ComposeManag
 	}
 }
 
+// SetAccessories sets the accessory service names. They are filtered by
+// DeployWithOptions and protected from orphan removal when absent from
+// the deployed content.
+func (m *ComposeManager) SetAccessories(names []string) {
+	m.accessories = names
+}
+
 // Deploy deploys using docker compose
 func (m *ComposeManager) Deploy(composeContent []byte) error {
 	// Ensure history container is running for rollback support
@@ -41,8 +53,12 @@ func (m *ComposeManager) Deploy(composeContent []byte) error {
 		return fmt.Errorf("failed to write compose file: %w", err)
 	}
 
-	// Deploy with docker compose
-	cmd := fmt.Sprintf("docker compose -p %s -f %s up -d --remove-orphans", m.projectName, composePath)
+	// Deploy with docker compose. Orphan removal is skipped when accessories
+	// were left out of the file, otherwise they would be torn down.
+	cmd := fmt.Sprintf("docker compose -p %s -f %s up -d", m.projectName, composePath)
+	if !m.missingAccessories(composeContent) {
+		cmd += " --remove-orphans"
+	}
 	result, err := m.exec.Run(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to deploy: %w", err)
@@ -171,8 +187,9 @@ func (m *ComposeManager) GetServiceLogs(serviceName string, follow bool, since s
 	return result.Stdout + result.Stderr, nil
 }
 
-// StreamServiceLogs streams logs from a service
-func (m *ComposeManager) StreamServiceLogs(serviceName string, follow bool, tail int, stdout, stderr io.Writer) error {
+// StreamServiceLogs streams logs from a service until it ends or ctx is
+// done
+func (m *ComposeManager) StreamServiceLogs(ctx context.Context, serviceName string, follow bool, tail int, stdout, stderr io.Writer) error {
 	cmd := fmt.Sprintf("docker compose -p %s logs %s", m.projectName, serviceName)
 	if tail > 0 {
 		cmd += fmt.Sprintf(" --tail %d", tail)
@@ -181,7 +198,7 @@ func (m *ComposeManager) StreamServiceLogs(serviceName string, follow bool, tail
 		cmd += " --follow"
 	}
 
-	return m.exec.RunStream(cmd, stdout, stderr)
+	return m.exec.RunStreamContext(ctx, cmd, stdout, stderr)
 }
 
 // FindRunningContainer finds a running container ID for a service
@@ -319,43 +336,93 @@ func (m *ComposeManager) GetMode() string {
 
 // extractImages extracts image names from compose content
 func (m *ComposeManager) extractImages(composeContent []byte) map[string]string {
-	images := make(map[string]string)
+	project, err := compose.Parse(composeContent)
+	if err != nil {
+		return map[string]string{}
+	}
+	return project.Images()
+}
 
-	// Simple extraction - look for image: lines
-	lines := strings.Split(string(composeContent), "\n")
-	var currentService string
+// DeployWithOptions deploys with filtering options
+func (m *ComposeManager) DeployWithOptions(composeContent []byte, options DeployOptions) error {
+	if options.SkipAccessories && len(m.accessories) > 0 {
+		filtered, err := removeServices(composeContent, m.accessories)
+		if err != nil {
+			return fmt.Errorf("failed to filter accessories: %w", err)
+		}
+		composeContent = filtered
+	}
 
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
+	if options.BaseDir == "" {
+		return m.Deploy(composeContent)
+	}
 
-		// Check if it's a service definition (ends with :)
-		if strings.HasSuffix(trimmed, ":") && !strings.HasPrefix(trimmed, "#") {
-			if !strings.Contains(trimmed, "image:") {
-				currentService = strings.TrimSuffix(trimmed, ":")
-			}
+	// docker compose bind-mounts file-based configs and secrets, so
+	// uploads must outlive the deploy. Files of the deploys in history are
+	// kept as well so a rollback can still find them.
+	filesDir := hostdir.Files(m.projectName)
+	dir := path.Join(filesDir, newDeployID())
+	content, created, err := uploadReferencedFiles(m.exec, composeContent, options.BaseDir, dir)
+	if err != nil {
+		if created {
+			removeDir(m.exec, dir)
 		}
+		return fmt.Errorf("failed to upload referenced files: %w", err)
+	}
 
-		// Check for image line
-		if strings.HasPrefix(trimmed, "image:") {
-			image := strings.TrimSpace(strings.TrimPrefix(trimmed, "image:"))
-			if currentService != "" {
-				images[currentService] = image
-			}
+	if err := m.Deploy(content); err != nil {
+		if created {
+			removeDir(m.exec, dir)
 		}
+		return err
+	}
+
+	if created {
+		m.pruneUploads(filesDir, dir)
 	}
+	return nil
+}
 
-	return images
+// pruneUploads removes the upload directories under filesDir that neither
+// the current deploy nor any deploy kept in history references. Nothing
+// is removed if the history can't be read.
+func (m *ComposeManager) pruneUploads(filesDir, current string) {
+	records, err := m.history.List(history.DefaultRetention)
+	if err != nil {
+		return
+	}
+
+	pruneDirs(m.exec, filesDir, func(dir string) bool {
+		if dir == current {
+			return true
+		}
+		for _, record := range records {
+			if strings.Contains(record.ComposeContent, dir+"/") {
+				return true
+			}
+		}
+		return false
+	})
 }
 
-// DeployWithOptions deploys with filtering options
-func (m *ComposeManager) DeployWithOptions(composeContent []byte, options DeployOptions) error {
-	// For now, ignore options and use regular Deploy
-	// TODO: Implement filtering for SkipAccessories
-	if options.SkipAccessories {
-		// TODO: Filter out accessories from compose content
+// missingAccessories reports whether any configured accessory is absent
+// from the compose content
+func (m *ComposeManager) missingAccessories(composeContent []byte) bool {
+	if len(m.accessories) == 0 {
+		return false
 	}
 
-	return m.Deploy(composeContent)
+	project, err := compose.Parse(composeContent)
+	if err != nil {
+		return false
+	}
+
+	for _, name := range m.accessories {
+		if _, ok := project.Services[name]; !ok {
+			return true
+		}
+	}
+	return false
 }
 
 // GetHistory returns the history manager
@@ -1,6 +1,7 @@
 package deployment
 
 import (
+	"context"
 	"errors"
 	"io"
 	"strings"
@@ -20,6 +21,8 @@ type MockExecutor struct {
 
 	writeFiles  map[string][]byte
 	writeErrors map[string]error
+
+	remote bool
 }
 
 func NewMockExecutor() *MockExecutor {
@@ -65,6 +68,22 @@ func (m *MockExecutor) RunStream(cmd string, stdout, stderr io.Writer) error {
 	return nil
 }
 
+func (m *MockExecutor) RunContext(ctx context.Context, cmd string) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
+func (m *MockExecutor) RunInteractiveContext(ctx context.Context, cmd string) error {
+	return m.RunInteractive(cmd)
+}
+
+func (m *MockExecutor) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	return m.RunStream(cmd, stdout, stderr)
+}
+
+func (m *MockExecutor) RunWithStdin(cmd string, stdin io.Reader) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
 func (m *MockExecutor) WriteFile(path string, content []byte) error {
 	m.writeFiles[path] = content
 	if err, exists := m.writeErrors[path]; exists {
@@ -78,7 +97,7 @@ func (m *MockExecutor) Close() error {
 }
 
 func (m *MockExecutor) IsLocal() bool {
-	return true
+	return !m.remote
 }
 
 func (m *MockExecutor) SetVerbose(v bool) {
@@ -193,6 +212,70 @@ func TestComposeManager_Deploy_DeployError(t *testing.T) {
 	}
 }
 
+const accessoriesCompose = `services:
+  web:
+    image: myapp:latest
+    depends_on:
+      - redis
+    networks:
+      - backend
+  redis:
+    image: redis:7-alpine
+    volumes:
+      - redis_data:/data
+networks:
+  backend: {}
+volumes:
+  redis_data: {}
+`
+
+func TestComposeManager_DeployWithOptions_SkipAccessories(t *testing.T) {
+	mockExec := NewMockExecutor()
+	manager := NewComposeManager(mockExec, "test-project")
+	manager.SetAccessories([]string{"redis"})
+
+	err := manager.DeployWithOptions([]byte(accessoriesCompose), DeployOptions{SkipAccessories: true})
+	if err != nil {
+		t.Fatalf("DeployWithOptions() error = %v", err)
+	}
+
+	written := string(mockExec.GetWrittenFiles()["/tmp/test-project-compose.yaml"])
+	if strings.Contains(written, "redis:7-alpine") {
+		t.Errorf("accessory should be removed from compose:\n%s", written)
+	}
+	if !strings.Contains(written, "myapp:latest") {
+		t.Errorf("app service should be kept:\n%s", written)
+	}
+	if !strings.Contains(written, "redis_data") || !strings.Contains(written, "backend") {
+		t.Errorf("top-level networks and volumes should be kept:\n%s", written)
+	}
+
+	// Running accessories must not be removed as orphans
+	if !containsCommand(mockExec.GetRunCommands(), "docker compose -p test-project -f /tmp/test-project-compose.yaml up -d") {
+		t.Errorf("expected deploy without --remove-orphans, got %v", mockExec.GetRunCommands())
+	}
+}
+
+func TestComposeManager_DeployWithOptions_NoSkip(t *testing.T) {
+	mockExec := NewMockExecutor()
+	manager := NewComposeManager(mockExec, "test-project")
+	manager.SetAccessories([]string{"redis"})
+
+	err := manager.DeployWithOptions([]byte(accessoriesCompose), DeployOptions{})
+	if err != nil {
+		t.Fatalf("DeployWithOptions() error = %v", err)
+	}
+
+	written := string(mockExec.GetWrittenFiles()["/tmp/test-project-compose.yaml"])
+	if written != accessoriesCompose {
+		t.Errorf("content should be untouched without SkipAccessories:\n%s", written)
+	}
+
+	if !containsCommand(mockExec.GetRunCommands(), "docker compose -p test-project -f /tmp/test-project-compose.yaml up -d --remove-orphans") {
+		t.Errorf("expected deploy with --remove-orphans, got %v", mockExec.GetRunCommands())
+	}
+}
+
 func TestComposeManager_Remove(t *testing.T) {
 	mockExec := NewMockExecutor()
 	manager := NewComposeManager(mockExec, "test-project")
@@ -450,3 +533,30 @@ func containsCommand(commands []string, target string) bool {
 	}
 	return false
 }
+
+func TestSwarmManager_DeployWithOptions_SkipAccessories(t *testing.T) {
+	mockExec := NewMockExecutor()
+	manager := NewSwarmManager(mockExec, "test-stack")
+	manager.SetAccessories([]string{"redis"})
+
+	err := manager.DeployWithOptions([]byte(accessoriesCompose), DeployOptions{SkipAccessories: true})
+	if err != nil {
+		t.Fatalf("DeployWithOptions() error = %v", err)
+	}
+
+	written := string(mockExec.GetWrittenFiles()["/tmp/test-stack-compose.yaml"])
+	if strings.Contains(written, "redis:7-alpine") {
+		t.Errorf("accessory should be removed from compose:\n%s", written)
+	}
+	if strings.Contains(written, "depends_on") {
+		t.Errorf("depends_on on a skipped accessory should be removed:\n%s", written)
+	}
+	if !strings.Contains(written, "redis_data") {
+		t.Errorf("top-level volumes should be kept:\n%s", written)
+	}
+
+	cmd := "docker stack deploy -c /tmp/test-stack-compose.yaml test-stack --with-registry-auth"
+	if !containsCommand(mockExec.GetRunCommands(), cmd) {
+		t.Errorf("expected %q, got %v", cmd, mockExec.GetRunCommands())
+	}
+}
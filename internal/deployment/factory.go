@@ -9,8 +9,12 @@ import (
 func New(cfg *config.Config, exec executor.Executor) Manager {
 	switch cfg.Mode {
 	case config.ModeCompose:
-		return NewComposeManager(exec, cfg.Stack)
+		m := NewComposeManager(exec, cfg.Stack)
+		m.SetAccessories(cfg.Accessories)
+		return m
 	default:
-		return NewSwarmManager(exec, cfg.Stack)
+		m := NewSwarmManager(exec, cfg.Stack)
+		m.SetAccessories(cfg.Accessories)
+		return m
 	}
 }
@@ -0,0 +1,163 @@
+package deployment
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/marcelsud/swarmctl/internal/compose"
+	"github.com/marcelsud/swarmctl/internal/executor"
+	"github.com/marcelsud/swarmctl/internal/hostdir"
+)
+
+// fileReference points at a local path inside a parsed compose project
+type fileReference struct {
+	path     *string
+	optional bool
+}
+
+// referencedFiles returns the local files a compose project references:
+// top-level configs and secrets with file: and service env_file entries.
+// Files already under hostdir.Root, such as compose-mode secrets, live on the
+// host and are skipped.
+func referencedFiles(p *compose.Project) []fileReference {
+	var refs []fileReference
+	for _, resources := range []map[string]*compose.Resource{p.Configs, p.Secrets} {
+		for _, name := range sortedNames(resources) {
+			if r := resources[name]; r.File != "" && !r.External && !strings.HasPrefix(r.File, hostdir.Root+"/") {
+				refs = append(refs, fileReference{path: &r.File})
+			}
+		}
+	}
+	for _, name := range p.ServiceNames() {
+		envFiles := p.Services[name].EnvFile
+		for i := range envFiles {
+			optional := envFiles[i].Required != nil && !*envFiles[i].Required
+			refs = append(refs, fileReference{path: &envFiles[i].Path, optional: optional})
+		}
+	}
+	return refs
+}
+
+// uploadReferencedFiles copies the local files referenced by the compose
+// content into remoteDir and returns the content with their paths
+// rewritten. Relative paths are resolved against baseDir. With a local
+// executor nothing is copied; paths are only made absolute. The returned
+// bool reports whether remoteDir was created.
+func uploadReferencedFiles(exec executor.Executor, composeContent []byte, baseDir, remoteDir string) ([]byte, bool, error) {
+	project, err := compose.Parse(composeContent)
+	if err != nil {
+		return nil, false, err
+	}
+
+	refs := referencedFiles(project)
+	if len(refs) == 0 {
+		return composeContent, false, nil
+	}
+
+	if !exec.IsLocal() {
+		cmd := fmt.Sprintf("mkdir -p %s && chmod 700 %s", shellquote.Join(remoteDir), shellquote.Join(remoteDir))
+		result, err := exec.Run(cmd)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to create %s: %w", remoteDir, err)
+		}
+		if result.ExitCode != 0 {
+			return nil, false, fmt.Errorf("failed to create %s: %s", remoteDir, result.Stderr)
+		}
+	}
+
+	uploaded := make(map[string]string)
+	for _, ref := range refs {
+		local := *ref.path
+		if !filepath.IsAbs(local) {
+			local = filepath.Join(baseDir, local)
+		}
+
+		if _, err := os.Stat(local); err != nil && ref.optional {
+			continue
+		}
+
+		if exec.IsLocal() {
+			*ref.path = local
+			continue
+		}
+
+		remote, ok := uploaded[local]
+		if !ok {
+			data, err := os.ReadFile(local)
+			if err != nil {
+				return nil, true, fmt.Errorf("failed to read file referenced by compose file: %w", err)
+			}
+			// Prefix with an index so files with the same name don't collide
+			remote = path.Join(remoteDir, fmt.Sprintf("%d-%s", len(uploaded), filepath.Base(local)))
+			if err := exec.WriteFile(remote, data); err != nil {
+				return nil, true, fmt.Errorf("failed to upload %s: %w", local, err)
+			}
+			uploaded[local] = remote
+		}
+		*ref.path = remote
+	}
+
+	out, err := project.Marshal()
+	return out, !exec.IsLocal(), err
+}
+
+// newDeployID returns a sortable identifier for a deploy's upload directory
+func newDeployID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+// removeDir removes a remote directory, ignoring errors
+func removeDir(exec executor.Executor, dir string) {
+	exec.Run(fmt.Sprintf("rm -rf %s", shellquote.Join(dir)))
+}
+
+// pruneDirs removes the entries of a remote directory named by
+// newDeployID for which keep returns false. Other entries are left alone.
+// Entries are listed with plain ls, which every host has.
+func pruneDirs(exec executor.Executor, dir string, keep func(path string) bool) error {
+	result, err := exec.Run(fmt.Sprintf("ls -1 %s", shellquote.Join(dir)))
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("failed to list %s: %s", dir, result.Stderr)
+	}
+
+	var remove []string
+	for _, name := range strings.Fields(result.Stdout) {
+		if _, err := strconv.ParseInt(name, 10, 64); err != nil {
+			continue
+		}
+		if p := path.Join(dir, name); !keep(p) {
+			remove = append(remove, p)
+		}
+	}
+	if len(remove) == 0 {
+		return nil
+	}
+
+	result, err = exec.Run("rm -rf " + shellquote.Join(remove...))
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("failed to remove old uploads: %s", result.Stderr)
+	}
+	return nil
+}
+
+func sortedNames(resources map[string]*compose.Resource) []string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
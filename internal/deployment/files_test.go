@@ -0,0 +1,232 @@
+package deployment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marcelsud/swarmctl/internal/executor"
+)
+
+const filesCompose = `
+services:
+  web:
+    image: nginx
+    env_file:
+      - .env.web
+      - path: .env.optional
+        required: false
+    configs:
+      - nginx_conf
+    secrets:
+      - tls_key
+  worker:
+    image: worker
+    env_file: .env.web
+configs:
+  nginx_conf:
+    file: ./nginx.conf
+secrets:
+  tls_key:
+    file: ./certs/tls.key
+  db_url:
+    external: true
+`
+
+func writeReferencedFiles(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		".env.web":      "PORT=80\n",
+		"nginx.conf":    "server {}\n",
+		"certs/tls.key": "-----BEGIN KEY-----\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestUploadReferencedFiles_Remote(t *testing.T) {
+	baseDir := writeReferencedFiles(t)
+	mockExec := NewMockExecutor()
+	mockExec.remote = true
+
+	out, created, err := uploadReferencedFiles(mockExec, []byte(filesCompose), baseDir, "/tmp/upload")
+	if err != nil {
+		t.Fatalf("uploadReferencedFiles() error = %v", err)
+	}
+	if !created {
+		t.Error("expected remote directory to be created")
+	}
+
+	if !containsCommand(mockExec.GetRunCommands(), "mkdir -p /tmp/upload && chmod 700 /tmp/upload") {
+		t.Errorf("expected mkdir command, got %v", mockExec.GetRunCommands())
+	}
+
+	written := mockExec.GetWrittenFiles()
+	if string(written["/tmp/upload/0-nginx.conf"]) != "server {}\n" {
+		t.Errorf("nginx.conf not uploaded, got %v", keys(written))
+	}
+	if string(written["/tmp/upload/1-tls.key"]) != "-----BEGIN KEY-----\n" {
+		t.Errorf("tls.key not uploaded, got %v", keys(written))
+	}
+	if string(written["/tmp/upload/2-.env.web"]) != "PORT=80\n" {
+		t.Errorf(".env.web not uploaded, got %v", keys(written))
+	}
+	if len(written) != 3 {
+		t.Errorf("shared files should be uploaded once, got %v", keys(written))
+	}
+
+	content := string(out)
+	for _, want := range []string{"file: /tmp/upload/0-nginx.conf", "file: /tmp/upload/1-tls.key", "- /tmp/upload/2-.env.web", "external: true"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in rewritten compose:\n%s", want, content)
+		}
+	}
+	if !strings.Contains(content, ".env.optional") {
+		t.Errorf("missing optional env_file should be left as-is:\n%s", content)
+	}
+}
+
+func TestUploadReferencedFiles_Local(t *testing.T) {
+	baseDir := writeReferencedFiles(t)
+	mockExec := NewMockExecutor()
+
+	out, created, err := uploadReferencedFiles(mockExec, []byte(filesCompose), baseDir, "/tmp/upload")
+	if err != nil {
+		t.Fatalf("uploadReferencedFiles() error = %v", err)
+	}
+	if created || len(mockExec.GetWrittenFiles()) != 0 || len(mockExec.GetRunCommands()) != 0 {
+		t.Error("local executor should not upload anything")
+	}
+	if !strings.Contains(string(out), "file: "+filepath.Join(baseDir, "nginx.conf")) {
+		t.Errorf("paths should be made absolute:\n%s", out)
+	}
+}
+
+func TestUploadReferencedFiles_Missing(t *testing.T) {
+	mockExec := NewMockExecutor()
+	mockExec.remote = true
+
+	_, _, err := uploadReferencedFiles(mockExec, []byte(filesCompose), t.TempDir(), "/tmp/upload")
+	if err == nil {
+		t.Fatal("expected error for missing referenced file")
+	}
+}
+
+func TestUploadReferencedFiles_SkipsHostFiles(t *testing.T) {
+	mockExec := NewMockExecutor()
+	mockExec.remote = true
+
+	content := []byte("services:\n  web:\n    image: nginx\nsecrets:\n  db_url:\n    file: /var/lib/swarmctl/myapp/secrets/myapp_db_url_0123456789ab\n")
+	out, created, err := uploadReferencedFiles(mockExec, content, t.TempDir(), "/tmp/upload")
+	if err != nil {
+		t.Fatalf("uploadReferencedFiles() error = %v", err)
+	}
+	if created || string(out) != string(content) {
+		t.Errorf("files already on the host should be left alone, got:\n%s", out)
+	}
+}
+
+func TestUploadReferencedFiles_NoReferences(t *testing.T) {
+	mockExec := NewMockExecutor()
+	mockExec.remote = true
+
+	content := []byte("services:\n  web:\n    image: nginx\n")
+	out, created, err := uploadReferencedFiles(mockExec, content, t.TempDir(), "/tmp/upload")
+	if err != nil {
+		t.Fatalf("uploadReferencedFiles() error = %v", err)
+	}
+	if created || string(out) != string(content) {
+		t.Error("content without file references should be returned unchanged")
+	}
+}
+
+func TestSwarmManager_DeployWithOptions_UploadsFiles(t *testing.T) {
+	baseDir := writeReferencedFiles(t)
+	mockExec := NewMockExecutor()
+	mockExec.remote = true
+	manager := NewSwarmManager(mockExec, "test-stack")
+
+	if err := manager.DeployWithOptions([]byte(filesCompose), DeployOptions{BaseDir: baseDir}); err != nil {
+		t.Fatalf("DeployWithOptions() error = %v", err)
+	}
+
+	written := string(mockExec.GetWrittenFiles()["/tmp/test-stack-compose.yaml"])
+	if !strings.Contains(written, "/tmp/swarmctl-test-stack-") {
+		t.Errorf("compose should reference uploaded files:\n%s", written)
+	}
+
+	commands := mockExec.GetRunCommands()
+	last := commands[len(commands)-1]
+	if !strings.HasPrefix(last, "rm -rf /tmp/swarmctl-test-stack-") {
+		t.Errorf("upload directory should be removed after deploy, got %v", commands)
+	}
+}
+
+func TestComposeManager_DeployWithOptions_UploadsFiles(t *testing.T) {
+	baseDir := writeReferencedFiles(t)
+	mockExec := NewMockExecutor()
+	mockExec.remote = true
+	manager := NewComposeManager(mockExec, "test-project")
+
+	// 200 is still used by a deploy in history, 100 and 300 by none
+	filesDir := "/var/lib/swarmctl/test-project/files"
+	mockExec.SetRunResult("ls -1 "+filesDir, &executor.CommandResult{Stdout: "100\n200\n300\nlost+found\n"})
+	mockExec.SetRunResult("docker exec test-project-history /app/history list --stack test-project --limit 10 --format json", &executor.CommandResult{
+		Stdout: `[{"id": 1, "compose_content": "configs:\n  nginx:\n    file: /var/lib/swarmctl/test-project/files/200/0-nginx.conf\n"}]`,
+	})
+
+	if err := manager.DeployWithOptions([]byte(filesCompose), DeployOptions{BaseDir: baseDir}); err != nil {
+		t.Fatalf("DeployWithOptions() error = %v", err)
+	}
+
+	written := string(mockExec.GetWrittenFiles()["/tmp/test-project-compose.yaml"])
+	if !strings.Contains(written, filesDir+"/") {
+		t.Errorf("compose should reference uploaded files:\n%s", written)
+	}
+
+	prune := "rm -rf " + filesDir + "/100 " + filesDir + "/300"
+	if !containsCommand(mockExec.GetRunCommands(), prune) {
+		t.Errorf("expected uploads unused by history to be pruned, got %v", mockExec.GetRunCommands())
+	}
+}
+
+func TestComposeManager_DeployWithOptions_KeepsUploadsWithoutHistory(t *testing.T) {
+	baseDir := writeReferencedFiles(t)
+	mockExec := NewMockExecutor()
+	mockExec.remote = true
+	manager := NewComposeManager(mockExec, "test-project")
+
+	mockExec.SetRunResult("ls -1 /var/lib/swarmctl/test-project/files", &executor.CommandResult{Stdout: "100\n200\n"})
+	mockExec.SetRunResult("docker exec test-project-history /app/history list --stack test-project --limit 10 --format json", &executor.CommandResult{
+		ExitCode: 1,
+		Stderr:   "database is locked",
+	})
+
+	if err := manager.DeployWithOptions([]byte(filesCompose), DeployOptions{BaseDir: baseDir}); err != nil {
+		t.Fatalf("DeployWithOptions() error = %v", err)
+	}
+
+	for _, cmd := range mockExec.GetRunCommands() {
+		if strings.HasPrefix(cmd, "rm -rf /var/lib/swarmctl") {
+			t.Errorf("nothing should be pruned when history can't be read, ran %q", cmd)
+		}
+	}
+}
+
+func keys(m map[string][]byte) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
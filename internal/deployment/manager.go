@@ -1,9 +1,12 @@
 package deployment
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
+
+	"github.com/marcelsud/swarmctl/internal/compose"
 )
 
 // ServiceStatus represents the status of a service
@@ -17,7 +20,15 @@ type ServiceStatus struct {
 
 // DeployOptions holds options for deployment
 type DeployOptions struct {
+	// SkipAccessories removes the configured accessory services from the
+	// compose content so running accessories are left untouched
 	SkipAccessories bool
+
+	// BaseDir is the local directory relative paths in the compose content
+	// are resolved against. When set, files referenced by the compose
+	// content (configs and secrets with file:, env_file) are uploaded to
+	// the remote host and their paths rewritten.
+	BaseDir string
 }
 
 // ContainerInfo holds information about a running container including its node
@@ -57,7 +68,8 @@ type Manager interface {
 	GetServiceLogs(serviceName string, follow bool, since string, tail int) (string, error)
 
 	// StreamServiceLogs streams logs from a service to the provided writers
-	StreamServiceLogs(serviceName string, follow bool, tail int, stdout, stderr io.Writer) error
+	// until it ends or ctx is done
+	StreamServiceLogs(ctx context.Context, serviceName string, follow bool, tail int, stdout, stderr io.Writer) error
 
 	// FindRunningContainer finds a container ID for exec
 	FindRunningContainer(serviceName string) (string, error)
@@ -113,3 +125,14 @@ func NewUnsupportedError(operation, mode string) error {
 		Mode:      mode,
 	}
 }
+
+// removeServices strips the named services from compose content, keeping
+// the networks, volumes, secrets and configs they reference
+func removeServices(composeContent []byte, names []string) ([]byte, error) {
+	project, err := compose.Parse(composeContent)
+	if err != nil {
+		return nil, err
+	}
+	project.RemoveServices(names...)
+	return project.Marshal()
+}
@@ -1,6 +1,7 @@
 package deployment
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -11,8 +12,9 @@ import (
 
 // SwarmManager implements Manager for Docker Swarm deployments
 type SwarmManager struct {
-	exec      executor.Executor
-	stackName string
+	exec        executor.Executor
+	stackName   string
+	accessories []string
 }
 
 // NewSwarmManager creates a new SwarmManager
@@ -23,6 +25,11 @@ func NewSwarmManager(exec executor.Executor, stackName string) *SwarmManager {
 	}
 }
 
+// SetAccessories sets the accessory service names used by DeployWithOptions
+func (m *SwarmManager) SetAccessories(names []string) {
+	m.accessories = names
+}
+
 // Deploy deploys a stack using docker stack deploy
 func (m *SwarmManager) Deploy(composeContent []byte) error {
 	// Write compose file
@@ -50,12 +57,30 @@ func (m *SwarmManager) Deploy(composeContent []byte) error {
 
 // DeployWithOptions deploys with filtering options
 func (m *SwarmManager) DeployWithOptions(composeContent []byte, options DeployOptions) error {
-	// For now, ignore options and use regular Deploy
-	// TODO: Implement filtering for SkipAccessories
-	if options.SkipAccessories {
-		// TODO: Filter out accessories from compose content
+	if options.SkipAccessories && len(m.accessories) > 0 {
+		filtered, err := removeServices(composeContent, m.accessories)
+		if err != nil {
+			return fmt.Errorf("failed to filter accessories: %w", err)
+		}
+		composeContent = filtered
+	}
+
+	// docker stack deploy reads referenced files once to create configs
+	// and secrets, so the upload directory is only needed during deploy
+	if options.BaseDir != "" {
+		dir := fmt.Sprintf("/tmp/swarmctl-%s-%s", m.stackName, newDeployID())
+		content, created, err := uploadReferencedFiles(m.exec, composeContent, options.BaseDir, dir)
+		if created {
+			defer removeDir(m.exec, dir)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to upload referenced files: %w", err)
+		}
+		composeContent = content
 	}
 
+	// docker stack deploy never prunes services missing from the file,
+	// so skipped accessories keep running untouched
 	return m.Deploy(composeContent)
 }
 
@@ -144,8 +169,9 @@ func (m *SwarmManager) GetServiceLogs(serviceName string, follow bool, since str
 	return result.Stdout + result.Stderr, nil
 }
 
-// StreamServiceLogs streams logs from a service
-func (m *SwarmManager) StreamServiceLogs(serviceName string, follow bool, tail int, stdout, stderr io.Writer) error {
+// StreamServiceLogs streams logs from a service until it ends or ctx is
+// done
+func (m *SwarmManager) StreamServiceLogs(ctx context.Context, serviceName string, follow bool, tail int, stdout, stderr io.Writer) error {
 	fullName := fmt.Sprintf("%s_%s", m.stackName, serviceName)
 
 	cmd := fmt.Sprintf("docker service logs %s", fullName)
@@ -156,7 +182,7 @@ func (m *SwarmManager) StreamServiceLogs(serviceName string, follow bool, tail i
 		cmd += " --follow"
 	}
 
-	return m.exec.RunStream(cmd, stdout, stderr)
+	return m.exec.RunStreamContext(ctx, cmd, stdout, stderr)
 }
 
 // FindRunningContainer finds a running container ID for a service
@@ -0,0 +1,289 @@
+package dotenv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/marcelsud/swarmctl/internal/interpolate"
+)
+
+// ParseError reports a malformed line in a .env file
+type ParseError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Read parses the .env file at path
+func Read(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open .env file: %w", err)
+	}
+	defer file.Close()
+
+	env, err := Parse(file)
+	if perr, ok := err.(*ParseError); ok {
+		perr.File = path
+	}
+	return env, err
+}
+
+// Parse parses a .env file, following docker compose rules:
+//
+//	KEY=value              unquoted; an inline comment starts at " #"
+//	export KEY=value       the export prefix is ignored
+//	KEY='value'            literal, may span lines
+//	KEY="value"            may span lines; \n, \r, \t, \", \\ and \$ escapes
+//
+// Unquoted and double-quoted values expand ${OTHER} from keys defined
+// earlier in the file, then from the process environment. Any other $,
+// such as $OTHER or $2y$10$ in a bcrypt hash, is kept byte for byte.
+// Blank lines, comments and bare KEY lines are skipped.
+func Parse(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .env file: %w", err)
+	}
+
+	p := &parser{
+		src:  strings.ReplaceAll(string(data), "\r\n", "\n"),
+		line: 1,
+		env:  make(map[string]string),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.env, nil
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+	env  map[string]string
+}
+
+func (p *parser) parse() error {
+	for p.pos < len(p.src) {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			break
+		}
+
+		switch p.src[p.pos] {
+		case '\n':
+			p.pos++
+			p.line++
+			continue
+		case '#':
+			p.skipLine()
+			continue
+		}
+
+		if err := p.parseEntry(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseEntry parses a KEY=value entry starting at the current position
+func (p *parser) parseEntry() error {
+	line := p.line
+	if strings.HasPrefix(p.src[p.pos:], "export") && p.pos+6 < len(p.src) && isBlank(p.src[p.pos+6]) {
+		p.pos += 6
+		p.skipSpace()
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && isKeyChar(p.src[p.pos]) {
+		p.pos++
+	}
+	key := p.src[start:p.pos]
+	p.skipSpace()
+
+	if p.pos >= len(p.src) || p.src[p.pos] == '\n' || p.src[p.pos] == '#' {
+		if key == "" {
+			return p.errorf(line, "invalid line")
+		}
+		// A bare KEY has no value to set
+		p.skipLine()
+		return nil
+	}
+	if key == "" || !isKeyStart(key[0]) {
+		return p.errorf(line, "invalid variable name %q", strings.TrimSpace(p.restOfLine(start)))
+	}
+	if p.src[p.pos] != '=' {
+		return p.errorf(line, "unexpected character %q in variable name %s", p.src[p.pos], key)
+	}
+	p.pos++
+	p.skipSpace()
+
+	var value string
+	var err error
+	if p.pos < len(p.src) && (p.src[p.pos] == '\'' || p.src[p.pos] == '"') {
+		value, err = p.parseQuoted(key, line)
+	} else {
+		value, err = p.parseUnquoted(key, line)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.env[key] = value
+	return nil
+}
+
+// parseUnquoted parses a value up to the end of the line or an inline comment
+func (p *parser) parseUnquoted(key string, line int) (string, error) {
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		if p.src[p.pos] == '#' && p.pos > start && isBlank(p.src[p.pos-1]) {
+			break
+		}
+		p.pos++
+	}
+	raw := strings.TrimRight(p.src[start:p.pos], " \t")
+	p.skipLine()
+	return p.expand(raw, key, line)
+}
+
+// parseQuoted parses a single or double quoted value, which may span lines
+func (p *parser) parseQuoted(key string, line int) (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+
+	// Only the parts of double-quoted values outside \$ escapes expand,
+	// so value collects expanded parts and b the part being read
+	var value, b strings.Builder
+	for {
+		if p.pos >= len(p.src) {
+			return "", p.errorf(line, "unterminated quoted value for %s", key)
+		}
+		c := p.src[p.pos]
+		if c == quote {
+			p.pos++
+			break
+		}
+		if c == '\n' {
+			p.line++
+		}
+		if c == '\\' && quote == '"' && p.pos+1 < len(p.src) {
+			p.pos++
+			switch next := p.src[p.pos]; next {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(next)
+			case '$':
+				expanded, err := p.expand(b.String(), key, line)
+				if err != nil {
+					return "", err
+				}
+				value.WriteString(expanded)
+				value.WriteByte('$')
+				b.Reset()
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(next)
+				if next == '\n' {
+					p.line++
+				}
+			}
+			p.pos++
+			continue
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+
+	// Only a comment may follow the closing quote
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '#' {
+		return "", p.errorf(p.line, "unexpected characters after quoted value for %s", key)
+	}
+	p.skipLine()
+
+	// Single quoted values are literal
+	if quote == '\'' {
+		return b.String(), nil
+	}
+	expanded, err := p.expand(b.String(), key, line)
+	if err != nil {
+		return "", err
+	}
+	value.WriteString(expanded)
+	return value.String(), nil
+}
+
+// expand substitutes ${...} variables defined earlier in the file or in
+// the process environment
+func (p *parser) expand(s, key string, line int) (string, error) {
+	value, err := interpolate.ExpandBraced(s, func(name string) (string, bool) {
+		if v, ok := p.env[name]; ok {
+			return v, true
+		}
+		return os.LookupEnv(name)
+	})
+	if err != nil {
+		return "", p.errorf(line, "%s: %v", key, err)
+	}
+	return value, nil
+}
+
+// skipSpace skips spaces and tabs
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && isBlank(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// skipLine moves past the end of the current line
+func (p *parser) skipLine() {
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+	if p.pos < len(p.src) {
+		p.pos++
+		p.line++
+	}
+}
+
+// restOfLine returns the text from start to the end of its line
+func (p *parser) restOfLine(start int) string {
+	end := strings.IndexByte(p.src[start:], '\n')
+	if end < 0 {
+		return p.src[start:]
+	}
+	return p.src[start : start+end]
+}
+
+func (p *parser) errorf(line int, format string, args ...interface{}) error {
+	return &ParseError{Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isKeyStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isKeyChar(c byte) bool {
+	return isKeyStart(c) || (c >= '0' && c <= '9') || c == '.' || c == '-'
+}
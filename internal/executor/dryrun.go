@@ -0,0 +1,342 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// DryRunExecutor runs read-only commands with the wrapped executor and
+// records every other command in a plan instead of running it. Recorded
+// commands succeed with empty output. Close prints the plan.
+type DryRunExecutor struct {
+	exec   Executor
+	out    io.Writer
+	plan   []string
+	redact []string
+}
+
+// NewDryRun creates a DryRunExecutor wrapping exec that prints its plan
+// to out
+func NewDryRun(exec Executor, out io.Writer) *DryRunExecutor {
+	return &DryRunExecutor{exec: exec, out: out}
+}
+
+// minRedactLen is the length below which values are not redacted, as
+// they would mask unrelated parts of commands
+const minRedactLen = 4
+
+// Redact hides values, such as passwords, wherever they appear in the plan
+func (e *DryRunExecutor) Redact(values ...string) {
+	for _, v := range values {
+		if len(v) >= minRedactLen {
+			e.redact = append(e.redact, v)
+		}
+	}
+}
+
+// Plan returns the commands recorded so far
+func (e *DryRunExecutor) Plan() []string {
+	return append([]string(nil), e.plan...)
+}
+
+// record adds a step to the plan, with redacted values masked
+func (e *DryRunExecutor) record(step string) {
+	for _, v := range e.redact {
+		step = strings.ReplaceAll(step, v, "[REDACTED]")
+	}
+	e.plan = append(e.plan, step)
+}
+
+// SetVerbose sets verbose mode on the wrapped executor
+func (e *DryRunExecutor) SetVerbose(v bool) {
+	e.exec.SetVerbose(v)
+}
+
+// Run runs cmd if it is read-only and records it otherwise
+func (e *DryRunExecutor) Run(cmd string) (*CommandResult, error) {
+	return e.RunContext(context.Background(), cmd)
+}
+
+// RunContext runs cmd if it is read-only and records it otherwise
+func (e *DryRunExecutor) RunContext(ctx context.Context, cmd string) (*CommandResult, error) {
+	if IsReadOnly(cmd) {
+		return e.exec.RunContext(ctx, cmd)
+	}
+	e.record(cmd)
+	return &CommandResult{}, nil
+}
+
+// RunWithStdin records cmd; its input is never shown, as it is how secret
+// values are passed
+func (e *DryRunExecutor) RunWithStdin(cmd string, stdin io.Reader) (*CommandResult, error) {
+	if IsReadOnly(cmd) {
+		return e.exec.RunWithStdin(cmd, stdin)
+	}
+	e.record(cmd + " < (stdin redacted)")
+	return &CommandResult{}, nil
+}
+
+// RunInteractive runs cmd if it is read-only and records it otherwise
+func (e *DryRunExecutor) RunInteractive(cmd string) error {
+	return e.RunInteractiveContext(context.Background(), cmd)
+}
+
+// RunInteractiveContext runs cmd if it is read-only and records it
+// otherwise
+func (e *DryRunExecutor) RunInteractiveContext(ctx context.Context, cmd string) error {
+	if IsReadOnly(cmd) {
+		return e.exec.RunInteractiveContext(ctx, cmd)
+	}
+	e.record(cmd)
+	return nil
+}
+
+// RunStream runs cmd if it is read-only and records it otherwise
+func (e *DryRunExecutor) RunStream(cmd string, stdout, stderr io.Writer) error {
+	return e.RunStreamContext(context.Background(), cmd, stdout, stderr)
+}
+
+// RunStreamContext runs cmd if it is read-only and records it otherwise
+func (e *DryRunExecutor) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	if IsReadOnly(cmd) {
+		return e.exec.RunStreamContext(ctx, cmd, stdout, stderr)
+	}
+	e.record(cmd)
+	return nil
+}
+
+// WriteFile records the write without its content
+func (e *DryRunExecutor) WriteFile(path string, content []byte) error {
+	e.record(fmt.Sprintf("write %s (%d bytes)", path, len(content)))
+	return nil
+}
+
+// Close prints the plan and closes the wrapped executor
+func (e *DryRunExecutor) Close() error {
+	if len(e.plan) == 0 {
+		fmt.Fprintf(e.out, "\n→ Dry run: nothing would be changed\n")
+	} else {
+		fmt.Fprintf(e.out, "\n→ Dry run: %d command(s) would be run:\n", len(e.plan))
+		for i, step := range e.plan {
+			fmt.Fprintf(e.out, "  %d. %s\n", i+1, step)
+		}
+	}
+	return e.exec.Close()
+}
+
+// IsLocal reports whether the wrapped executor runs locally
+func (e *DryRunExecutor) IsLocal() bool {
+	return e.exec.IsLocal()
+}
+
+// RunInteractiveOnHostContext records a command that would run on a
+// worker node
+func (e *DryRunExecutor) RunInteractiveOnHostContext(ctx context.Context, host, user, cmd string) error {
+	e.record(fmt.Sprintf("ssh %s@%s %s", user, host, cmd))
+	return nil
+}
+
+// readOnlyCommands lists commands that only read state, with the
+// subcommands that do when a command also has mutating ones
+var readOnlyCommands = map[string][]string{
+	"cat":   nil,
+	"cd":    nil,
+	"cut":   nil,
+	"echo":  nil,
+	"grep":  nil,
+	"head":  nil,
+	"ls":    nil,
+	"sort":  nil,
+	"tail":  nil,
+	"test":  nil,
+	"tr":    nil,
+	"true":  nil,
+	"uniq":  nil,
+	"wc":    nil,
+	"which": nil,
+	// The history sidecar, see internal/history
+	"/app/history": {"list", "get"},
+}
+
+// readOnlyDocker lists the read-only docker commands, and the read-only
+// subcommands of management commands such as docker service
+var readOnlyDocker = map[string][]string{
+	"events":    nil,
+	"images":    nil,
+	"info":      nil,
+	"inspect":   nil,
+	"logs":      nil,
+	"ps":        nil,
+	"top":       nil,
+	"version":   nil,
+	"compose":   {"config", "images", "logs", "ls", "ps", "top", "version"},
+	"config":    {"inspect", "ls"},
+	"container": {"inspect", "logs", "ls", "ps", "top"},
+	"context":   {"inspect", "ls", "show"},
+	"image":     {"inspect", "ls"},
+	"network":   {"inspect", "ls"},
+	"node":      {"inspect", "ls", "ps"},
+	"secret":    {"inspect", "ls"},
+	"service":   {"inspect", "logs", "ls", "ps"},
+	"stack":     {"ls", "ps", "services"},
+	"system":    {"df", "info"},
+	"volume":    {"inspect", "ls"},
+}
+
+// Flags that take a value, for the docker commands whose arguments are
+// inspected
+var (
+	dockerValueFlags  = []string{"-H", "--host", "-c", "--context", "--config", "-l", "--log-level"}
+	composeValueFlags = []string{"-p", "--project-name", "-f", "--file", "--env-file", "--profile", "--project-directory", "--ansi", "--progress"}
+	execValueFlags    = []string{"-e", "--env", "--env-file", "-u", "--user", "-w", "--workdir"}
+)
+
+// IsReadOnly reports whether cmd only reads state: every command in it,
+// split on pipes, &&, || and ;, is a known read-only command, output is
+// only redirected to /dev/null or another descriptor, and it has no
+// command substitution
+func IsReadOnly(cmd string) bool {
+	if strings.Contains(cmd, "$(") || strings.Contains(cmd, "`") {
+		return false
+	}
+
+	readOnly := false
+	for _, part := range splitCommands(cmd) {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		words, err := shellquote.Split(part)
+		if err != nil {
+			return false
+		}
+		args, ok := stripRedirects(words)
+		if !ok || !readOnlyArgs(args) {
+			return false
+		}
+		readOnly = true
+	}
+	return readOnly
+}
+
+// readOnlyArgs reports whether a single command only reads state
+func readOnlyArgs(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if args[0] == "docker" {
+		return readOnlyDockerArgs(args[1:])
+	}
+	subcommands, ok := readOnlyCommands[args[0]]
+	if !ok {
+		return false
+	}
+	return subcommands == nil || (len(args) > 1 && slices.Contains(subcommands, args[1]))
+}
+
+func readOnlyDockerArgs(args []string) bool {
+	args = skipFlags(args, dockerValueFlags)
+	if len(args) == 0 {
+		// docker --version
+		return true
+	}
+
+	if args[0] == "exec" {
+		// docker exec [flags] CONTAINER COMMAND...
+		rest := skipFlags(args[1:], execValueFlags)
+		return len(rest) > 1 && readOnlyArgs(rest[1:])
+	}
+
+	subcommands, ok := readOnlyDocker[args[0]]
+	if !ok {
+		return false
+	}
+	if subcommands == nil {
+		return true
+	}
+
+	rest := args[1:]
+	if args[0] == "compose" {
+		rest = skipFlags(rest, composeValueFlags)
+	}
+	return len(rest) > 0 && slices.Contains(subcommands, rest[0])
+}
+
+// skipFlags drops the leading flags of args, and the values of the ones
+// in valueFlags
+func skipFlags(args []string, valueFlags []string) []string {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if slices.Contains(valueFlags, args[0]) {
+			args = args[1:]
+		}
+		if len(args) > 0 {
+			args = args[1:]
+		}
+	}
+	return args
+}
+
+// stripRedirects removes redirections from words. It fails when output is
+// redirected anywhere other than /dev/null or another file descriptor.
+func stripRedirects(words []string) ([]string, bool) {
+	var args []string
+	for i := 0; i < len(words); i++ {
+		word := strings.TrimLeft(words[i], "0123456789")
+		if !strings.HasPrefix(word, ">") && !strings.HasPrefix(word, "<") {
+			args = append(args, words[i])
+			continue
+		}
+
+		target := strings.TrimLeft(word, "<>")
+		if target == "" && i+1 < len(words) {
+			i++
+			target = words[i]
+		}
+		if strings.HasPrefix(word, ">") && target != "/dev/null" && !strings.HasPrefix(target, "&") {
+			return nil, false
+		}
+	}
+	return args, true
+}
+
+// splitCommands splits a command line on |, ||, &&, ; and & outside
+// quotes. Redirections such as 2>&1 are kept.
+func splitCommands(cmd string) []string {
+	var parts []string
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(cmd) {
+				b.WriteByte(c)
+				i++
+				c = cmd[i]
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '\\' && i+1 < len(cmd):
+			b.WriteByte(c)
+			i++
+			c = cmd[i]
+		case c == '&' && i > 0 && (cmd[i-1] == '>' || cmd[i-1] == '<'):
+			// Part of a redirection
+		case c == '|' || c == '&' || c == ';':
+			parts = append(parts, b.String())
+			b.Reset()
+			if i+1 < len(cmd) && cmd[i+1] == c {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return append(parts, b.String())
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestIsReadOnly(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"docker stack ls --format '{{.Name}}'", true},
+		{"docker service ls --filter name=myapp --format '{{.Name}}|{{.Replicas}}'", true},
+		{"docker service ps myapp_web --filter 'desired-state=running' --format '{{.ID}}' | head -1", true},
+		{"docker secret inspect --format '{{.Spec.Name}}' myapp_db", true},
+		{"docker inspect --format '{{.Status.ContainerStatus.ContainerID}}' abc", true},
+		{"docker info --format '{{.Swarm.LocalNodeState}}'", true},
+		{"docker --version", true},
+		{"docker compose -p myapp -f /tmp/c.yaml ps --format json", true},
+		{"docker ps --filter name=^myapp-history$ --format '{{.Names}}'", true},
+		{"docker exec myapp-history /app/history list --stack myapp --limit 2 --format json", true},
+		{"cat /var/lib/swarmctl/myapp/secrets/.salt 2>/dev/null", true},
+		{"ls -1t /var/lib/swarmctl/myapp/secrets 2>/dev/null", true},
+		{"docker stack deploy -c /tmp/myapp-compose.yaml --with-registry-auth myapp", false},
+		{"docker compose -p myapp -f /tmp/c.yaml up -d --remove-orphans", false},
+		{"docker secret create --label a=b myapp_db_abc -", false},
+		{"docker service scale myapp_redis=0", false},
+		{"docker service update --rollback myapp_web", false},
+		{"docker exec myapp-history /app/history record --stack myapp", false},
+		{"docker exec -it abc sh", false},
+		{"docker login ghcr.io -u me --password-stdin", false},
+		{"umask 077 && mkdir -p /d && cat > /d/f", false},
+		{"cat /etc/hostname > /tmp/x", false},
+		{"ls /tmp; rm -rf /tmp/x", false},
+		{"cd /d && ls -1 | sort -n | head -n -3 | xargs -r rm -rf", false},
+		{"echo $(rm -rf /)", false},
+		{"rm -f /tmp/x", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsReadOnly(tt.cmd); got != tt.want {
+			t.Errorf("IsReadOnly(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestDryRunExecutor(t *testing.T) {
+	var out bytes.Buffer
+	e := NewDryRun(NewLocal(), &out)
+	e.Redact("hunter2")
+
+	// Read-only commands run
+	result, err := e.Run("echo hello")
+	if err != nil || result.Stdout != "hello\n" {
+		t.Fatalf("Run() = %+v, %v, want read-only command to run", result, err)
+	}
+
+	// Mutating commands are only recorded
+	path := t.TempDir() + "/created"
+	result, err = e.Run("touch " + path)
+	if err != nil || result.ExitCode != 0 {
+		t.Fatalf("Run() = %+v, %v, want recorded command to succeed", result, err)
+	}
+	if _, err := e.Run("test -e " + path); err != nil {
+		t.Fatal(err)
+	}
+	if check, _ := NewLocal().Run("test -e " + path); check.ExitCode == 0 {
+		t.Error("mutating command was run")
+	}
+
+	e.RunWithStdin("docker secret create myapp_db -", strings.NewReader("s3cret value"))
+	e.Run("docker login ghcr.io -u me -p hunter2")
+	e.WriteFile("/tmp/myapp-compose.yaml", []byte("services: {}\n"))
+
+	wantPlan := []string{
+		"touch " + path,
+		"docker secret create myapp_db - < (stdin redacted)",
+		"docker login ghcr.io -u me -p [REDACTED]",
+		"write /tmp/myapp-compose.yaml (13 bytes)",
+	}
+	plan := e.Plan()
+	if len(plan) != len(wantPlan) {
+		t.Fatalf("Plan() = %q, want %q", plan, wantPlan)
+	}
+	for i := range wantPlan {
+		if plan[i] != wantPlan[i] {
+			t.Errorf("Plan()[%d] = %q, want %q", i, plan[i], wantPlan[i])
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	printed := out.String()
+	if !strings.Contains(printed, "4 command(s) would be run") || !strings.Contains(printed, "  2. docker secret create myapp_db - < (stdin redacted)") {
+		t.Errorf("Close() printed %q, want a numbered plan", printed)
+	}
+	if strings.Contains(printed, "s3cret") || strings.Contains(printed, "hunter2") {
+		t.Errorf("Close() printed a secret value: %q", printed)
+	}
+}
+
+func TestDryRunExecutor_EmptyPlan(t *testing.T) {
+	var out bytes.Buffer
+	e := NewDryRun(NewLocal(), &out)
+
+	var _ Executor = e
+
+	e.Close()
+	if !strings.Contains(out.String(), "nothing would be changed") {
+		t.Errorf("Close() printed %q, want an empty plan message", out.String())
+	}
+}
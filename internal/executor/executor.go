@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"context"
 	"io"
 
 	"github.com/marcelsud/swarmctl/internal/config"
@@ -18,12 +19,26 @@ type Executor interface {
 	// Run executes a command and returns the result
 	Run(cmd string) (*CommandResult, error)
 
+	// RunWithStdin executes a command with stdin fed from the reader.
+	// Use it to pass sensitive values, which must never be part of cmd.
+	RunWithStdin(cmd string, stdin io.Reader) (*CommandResult, error)
+
+	// RunContext is Run bound to ctx. When ctx is done the command is
+	// signalled and stopped, and ctx.Err() is returned.
+	RunContext(ctx context.Context, cmd string) (*CommandResult, error)
+
 	// RunInteractive runs a command with stdin/stdout/stderr attached
 	RunInteractive(cmd string) error
 
+	// RunInteractiveContext is RunInteractive bound to ctx
+	RunInteractiveContext(ctx context.Context, cmd string) error
+
 	// RunStream runs a command and streams output to the provided writers
 	RunStream(cmd string, stdout, stderr io.Writer) error
 
+	// RunStreamContext is RunStream bound to ctx
+	RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error
+
 	// WriteFile writes content to a file
 	WriteFile(path string, content []byte) error
 
@@ -40,9 +55,19 @@ type Executor interface {
 // New creates an Executor based on the configuration.
 // If SSH host is not configured, returns a LocalExecutor.
 // Otherwise, returns an SSHExecutor.
+// Either way, commands are limited by the configured timeouts.
 func New(cfg *config.Config) (Executor, error) {
+	timeouts := TimeoutsFrom(cfg.Timeouts)
 	if cfg.SSH.Host == "" {
-		return NewLocal(), nil
+		e := NewLocal()
+		e.SetTimeouts(timeouts)
+		return e, nil
+	}
+
+	e, err := NewSSH(cfg.SSH)
+	if err != nil {
+		return nil, err
 	}
-	return NewSSH(cfg.SSH)
+	e.SetTimeouts(timeouts)
+	return e, nil
 }
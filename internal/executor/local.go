@@ -2,15 +2,22 @@ package executor
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"time"
 )
 
+// killDelay is how long a cancelled local command has to exit after
+// SIGTERM before it is killed
+const killDelay = 5 * time.Second
+
 // LocalExecutor executes commands on the local machine
 type LocalExecutor struct {
-	verbose bool
+	verbose  bool
+	timeouts Timeouts
 }
 
 // NewLocal creates a new LocalExecutor
@@ -23,19 +30,45 @@ func (e *LocalExecutor) SetVerbose(v bool) {
 	e.verbose = v
 }
 
+// SetTimeouts sets the limits for each kind of command
+func (e *LocalExecutor) SetTimeouts(t Timeouts) {
+	e.timeouts = t
+}
+
 // Run executes a command locally and returns the result
 func (e *LocalExecutor) Run(cmd string) (*CommandResult, error) {
+	return e.run(context.Background(), cmd, nil)
+}
+
+// RunContext executes a command locally until it exits or ctx is done
+func (e *LocalExecutor) RunContext(ctx context.Context, cmd string) (*CommandResult, error) {
+	return e.run(ctx, cmd, nil)
+}
+
+// RunWithStdin executes a command locally with stdin fed from the reader
+func (e *LocalExecutor) RunWithStdin(cmd string, stdin io.Reader) (*CommandResult, error) {
+	return e.run(context.Background(), cmd, stdin)
+}
+
+func (e *LocalExecutor) run(ctx context.Context, cmd string, stdin io.Reader) (*CommandResult, error) {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Command)
+	defer cancel()
+
 	if e.verbose {
 		fmt.Fprintf(os.Stderr, "→ Running: %s\n", cmd)
 	}
 
-	c := exec.Command("sh", "-c", cmd)
+	c := command(ctx, cmd, false)
+	c.Stdin = stdin
 
 	var stdout, stderr bytes.Buffer
 	c.Stdout = &stdout
 	c.Stderr = &stderr
 
 	err := c.Run()
+	if err != nil && ctx.Err() != nil {
+		return nil, contextError(ctx, e.timeouts.Command, err)
+	}
 
 	result := &CommandResult{
 		Stdout:   stdout.String(),
@@ -68,21 +101,39 @@ func (e *LocalExecutor) Run(cmd string) (*CommandResult, error) {
 
 // RunInteractive runs a command with stdin/stdout/stderr attached
 func (e *LocalExecutor) RunInteractive(cmd string) error {
-	c := exec.Command("sh", "-c", cmd)
+	return e.RunInteractiveContext(context.Background(), cmd)
+}
+
+// RunInteractiveContext runs a command with stdin/stdout/stderr attached
+// until it exits or ctx is done
+func (e *LocalExecutor) RunInteractiveContext(ctx context.Context, cmd string) error {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Interactive)
+	defer cancel()
+
+	c := command(ctx, cmd, true)
 	c.Stdin = os.Stdin
 	c.Stdout = os.Stdout
 	c.Stderr = os.Stderr
 
-	return c.Run()
+	return contextError(ctx, e.timeouts.Interactive, c.Run())
 }
 
 // RunStream runs a command and streams output to the provided writers
 func (e *LocalExecutor) RunStream(cmd string, stdout, stderr io.Writer) error {
-	c := exec.Command("sh", "-c", cmd)
+	return e.RunStreamContext(context.Background(), cmd, stdout, stderr)
+}
+
+// RunStreamContext runs a command and streams output to the provided
+// writers until it exits or ctx is done
+func (e *LocalExecutor) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Stream)
+	defer cancel()
+
+	c := command(ctx, cmd, false)
 	c.Stdout = stdout
 	c.Stderr = stderr
 
-	return c.Run()
+	return contextError(ctx, e.timeouts.Stream, c.Run())
 }
 
 // WriteFile writes content to a local file
@@ -99,3 +150,13 @@ func (e *LocalExecutor) Close() error {
 func (e *LocalExecutor) IsLocal() bool {
 	return true
 }
+
+// command returns a shell command that is stopped when ctx is done, like
+// remote commands, and killed if it has not exited after killDelay. How it
+// is stopped depends on the platform; see setCancel.
+func command(ctx context.Context, cmd string, interactive bool) *exec.Cmd {
+	c := exec.CommandContext(ctx, "sh", "-c", cmd)
+	setCancel(c, interactive)
+	c.WaitDelay = killDelay
+	return c
+}
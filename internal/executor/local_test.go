@@ -2,8 +2,11 @@ package executor
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLocalExecutor_Run(t *testing.T) {
@@ -70,6 +73,55 @@ func TestLocalExecutor_Run_ExitCode(t *testing.T) {
 	}
 }
 
+func TestLocalExecutor_RunWithStdin(t *testing.T) {
+	e := NewLocal()
+
+	value := "it's a \"secret\"\n$(id)"
+	result, err := e.RunWithStdin("cat", strings.NewReader(value))
+	if err != nil {
+		t.Fatalf("RunWithStdin() unexpected error: %v", err)
+	}
+
+	if result.Stdout != value {
+		t.Errorf("RunWithStdin() stdout = %q, want %q", result.Stdout, value)
+	}
+}
+
+func TestLocalExecutor_Run_Timeout(t *testing.T) {
+	e := NewLocal()
+	e.SetTimeouts(Timeouts{Command: 100 * time.Millisecond})
+
+	start := time.Now()
+	_, err := e.Run("sleep 10")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run() error = %v, want deadline exceeded", err)
+	}
+	if !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("Run() error = %q, want the timeout in the message", err)
+	}
+	if elapsed := time.Since(start); elapsed > killDelay {
+		t.Errorf("Run() took %v, want the command stopped on timeout", elapsed)
+	}
+}
+
+func TestLocalExecutor_RunContext_Cancel(t *testing.T) {
+	e := NewLocal()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	_, err := e.RunContext(ctx, "sleep 10")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("RunContext() error = %v, want context canceled", err)
+	}
+
+	// A command that finishes in time is not affected
+	result, err := e.RunContext(context.Background(), "echo ok")
+	if err != nil || result.Stdout != "ok\n" {
+		t.Errorf("RunContext() = %v, %v, want ok", result, err)
+	}
+}
+
 func TestLocalExecutor_Run_InvalidCommand(t *testing.T) {
 	e := NewLocal()
 
@@ -145,6 +197,30 @@ func TestLocalExecutor_RunStream(t *testing.T) {
 	}
 }
 
+func TestLocalExecutor_RunStreamContext_Cancel(t *testing.T) {
+	e := NewLocal()
+	// The command timeout does not apply to streams
+	e.SetTimeouts(Timeouts{Command: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	var stdout, stderr bytes.Buffer
+	err := e.RunStreamContext(ctx, "echo started; sleep 10", &stdout, &stderr)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("RunStreamContext() error = %v, want context canceled", err)
+	}
+	if stdout.String() != "started\n" {
+		t.Errorf("RunStreamContext() stdout = %q, want output before cancellation", stdout.String())
+	}
+
+	e.SetTimeouts(Timeouts{Stream: 100 * time.Millisecond})
+	err = e.RunStream("sleep 10", &stdout, &stderr)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RunStream() error = %v, want deadline exceeded", err)
+	}
+}
+
 func TestLocalExecutor_WriteFile(t *testing.T) {
 	e := NewLocal()
 
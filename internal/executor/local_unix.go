@@ -0,0 +1,25 @@
+//go:build !windows
+
+package executor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setCancel makes c get SIGTERM when its context is done. Non-interactive
+// commands run in their own process group so the signal also reaches the
+// processes the shell started; interactive ones stay in the terminal's
+// group to keep reading from it.
+func setCancel(c *exec.Cmd, interactive bool) {
+	if interactive {
+		c.Cancel = func() error {
+			return c.Process.Signal(syscall.SIGTERM)
+		}
+		return
+	}
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGTERM)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package executor
+
+import "os/exec"
+
+// setCancel makes c get killed when its context is done. Windows has no
+// SIGTERM or process groups to signal, so the shell is killed right away.
+func setCancel(c *exec.Cmd, interactive bool) {
+	c.Cancel = func() error {
+		return c.Process.Kill()
+	}
+}
@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -11,13 +12,19 @@ import (
 
 // SSHExecutor executes commands on a remote machine via SSH
 type SSHExecutor struct {
-	client  *ssh.Client
-	verbose bool
+	client   *ssh.Client
+	verbose  bool
+	timeouts Timeouts
 }
 
 // NewSSH creates a new SSHExecutor and connects to the remote host
 func NewSSH(cfg config.SSHConfig) (*SSHExecutor, error) {
 	client := ssh.NewClient(cfg.Host, cfg.Port, cfg.User, cfg.Key)
+	client.KnownHostsFiles = cfg.KnownHostsFiles
+	client.StrictHostKeyChecking = cfg.StrictHostKeyChecking
+	for _, hop := range cfg.ProxyJump {
+		client.ProxyJump = append(client.ProxyJump, jumpClient(hop, cfg))
+	}
 
 	if err := client.Connect(); err != nil {
 		return nil, err
@@ -26,20 +33,64 @@ func NewSSH(cfg config.SSHConfig) (*SSHExecutor, error) {
 	return &SSHExecutor{client: client, verbose: false}, nil
 }
 
+// jumpClient returns the client for a jump host, with unset fields and
+// host key settings taken from the ssh section
+func jumpClient(hop config.JumpHost, cfg config.SSHConfig) *ssh.Client {
+	user, port, key := hop.User, hop.Port, hop.Key
+	if user == "" {
+		user = cfg.User
+	}
+	if port == 0 {
+		port = DefaultPort
+	}
+	if key == "" {
+		key = cfg.Key
+	}
+	client := ssh.NewClient(hop.Host, port, user, key)
+	client.KnownHostsFiles = cfg.KnownHostsFiles
+	client.StrictHostKeyChecking = cfg.StrictHostKeyChecking
+	return client
+}
+
 // SetVerbose sets verbose mode for command output
 func (e *SSHExecutor) SetVerbose(v bool) {
 	e.verbose = v
 }
 
+// SetTimeouts sets the limits for each kind of command
+func (e *SSHExecutor) SetTimeouts(t Timeouts) {
+	e.timeouts = t
+}
+
 // Run executes a command on remote host and returns: result
 func (e *SSHExecutor) Run(cmd string) (*CommandResult, error) {
+	return e.run(context.Background(), cmd, nil)
+}
+
+// RunContext executes a command on remote host until it exits or ctx is
+// done, in which case the remote command is sent SIGTERM and its session
+// closed
+func (e *SSHExecutor) RunContext(ctx context.Context, cmd string) (*CommandResult, error) {
+	return e.run(ctx, cmd, nil)
+}
+
+// RunWithStdin executes a command on remote host with stdin fed from the
+// reader. The input travels over the SSH channel, never on the command line.
+func (e *SSHExecutor) RunWithStdin(cmd string, stdin io.Reader) (*CommandResult, error) {
+	return e.run(context.Background(), cmd, stdin)
+}
+
+func (e *SSHExecutor) run(ctx context.Context, cmd string, stdin io.Reader) (*CommandResult, error) {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Command)
+	defer cancel()
+
 	if e.verbose {
 		fmt.Fprintf(os.Stderr, "→ Running: %s\n", cmd)
 	}
 
-	result, err := e.client.Run(cmd)
+	result, err := e.client.RunWithStdinContext(ctx, cmd, stdin)
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, e.timeouts.Command, err)
 	}
 
 	cmdResult := &CommandResult{
@@ -63,12 +114,50 @@ func (e *SSHExecutor) Run(cmd string) (*CommandResult, error) {
 
 // RunInteractive runs a command with stdin/stdout/stderr attached
 func (e *SSHExecutor) RunInteractive(cmd string) error {
-	return e.client.RunInteractive(cmd)
+	return e.RunInteractiveContext(context.Background(), cmd)
+}
+
+// RunInteractiveContext runs a command with stdin/stdout/stderr attached
+// until it exits or ctx is done
+func (e *SSHExecutor) RunInteractiveContext(ctx context.Context, cmd string) error {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Interactive)
+	defer cancel()
+
+	return contextError(ctx, e.timeouts.Interactive, e.client.RunInteractiveContext(ctx, cmd))
 }
 
-// RunInteractiveOnHost runs a command on a remote host through SSH hop
+// DefaultPort is the SSH port of the nodes reached through the manager
+// and of jump hosts without a port
+const DefaultPort = 22
+
+// Node returns an executor for a node reached through this connection,
+// such as a Swarm worker on the manager's internal network. ctx stops the
+// connection attempt. Close it when done; the manager connection stays
+// open.
+func (e *SSHExecutor) Node(ctx context.Context, host, user string) (*SSHExecutor, error) {
+	client, err := e.client.Jump(ctx, host, DefaultPort, user)
+	if err != nil {
+		return nil, err
+	}
+	return &SSHExecutor{client: client, verbose: e.verbose, timeouts: e.timeouts}, nil
+}
+
+// RunInteractiveOnHost runs a command on a node reached through this
+// connection
 func (e *SSHExecutor) RunInteractiveOnHost(host, user, cmd string) error {
-	return e.client.RunInteractiveViaHost(host, user, cmd)
+	return e.RunInteractiveOnHostContext(context.Background(), host, user, cmd)
+}
+
+// RunInteractiveOnHostContext runs a command on a node reached through
+// this connection until it exits or ctx is done
+func (e *SSHExecutor) RunInteractiveOnHostContext(ctx context.Context, host, user, cmd string) error {
+	node, err := e.Node(ctx, host, user)
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+
+	return node.RunInteractiveContext(ctx, cmd)
 }
 
 // HasAgentForwarding returns true if SSH agent forwarding is available
@@ -78,12 +167,24 @@ func (e *SSHExecutor) HasAgentForwarding() bool {
 
 // RunStream runs a command and streams output to the provided writers
 func (e *SSHExecutor) RunStream(cmd string, stdout, stderr io.Writer) error {
-	return e.client.RunStream(cmd, stdout, stderr)
+	return e.RunStreamContext(context.Background(), cmd, stdout, stderr)
+}
+
+// RunStreamContext runs a command and streams output to the provided
+// writers until it exits or ctx is done
+func (e *SSHExecutor) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Stream)
+	defer cancel()
+
+	return contextError(ctx, e.timeouts.Stream, e.client.RunStreamContext(ctx, cmd, stdout, stderr))
 }
 
 // WriteFile writes content to a file on the remote host
 func (e *SSHExecutor) WriteFile(path string, content []byte) error {
-	return e.client.WriteFile(path, content)
+	ctx, cancel := withTimeout(context.Background(), e.timeouts.Command)
+	defer cancel()
+
+	return contextError(ctx, e.timeouts.Command, e.client.WriteFileContext(ctx, path, content))
 }
 
 // Close closes the SSH connection
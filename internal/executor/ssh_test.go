@@ -2,6 +2,7 @@ package executor
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/marcelsud/swarmctl/internal/config"
@@ -114,6 +115,30 @@ func TestSSHExecutor_RunStream(t *testing.T) {
 	}
 }
 
+func TestSSHExecutor_RunWithStdin(t *testing.T) {
+	cfg := config.SSHConfig{
+		Host: "localhost",
+		User: "testuser",
+		Port: 22,
+		Key:  "/path/to/key",
+	}
+
+	executor, err := NewSSH(cfg)
+	if err != nil {
+		t.Skipf("SSH connection failed (expected in CI): %v", err)
+	}
+	defer executor.Close()
+
+	result, err := executor.RunWithStdin("cat", strings.NewReader("it's a secret"))
+	if err != nil {
+		t.Fatalf("RunWithStdin() error = %v", err)
+	}
+
+	if result.Stdout != "it's a secret" {
+		t.Errorf("RunWithStdin() stdout = %q, want %q", result.Stdout, "it's a secret")
+	}
+}
+
 func TestSSHExecutor_WriteFile(t *testing.T) {
 	cfg := config.SSHConfig{
 		Host: "localhost",
@@ -188,3 +213,22 @@ func TestSSHExecutor_HasAgentForwarding(t *testing.T) {
 	// Just test that the method doesn't panic
 	_ = executor.HasAgentForwarding()
 }
+
+func TestJumpClient_Defaults(t *testing.T) {
+	cfg := config.SSHConfig{
+		Host: "manager.internal",
+		User: "deploy",
+		Port: 22,
+		Key:  "/path/to/key",
+	}
+
+	hop := jumpClient(config.JumpHost{Host: "bastion.example.com"}, cfg)
+	if hop.Host != "bastion.example.com" || hop.User != "deploy" || hop.Port != DefaultPort || hop.KeyPath != "/path/to/key" {
+		t.Errorf("jumpClient() = %+v, want the ssh user and key on port %d", hop, DefaultPort)
+	}
+
+	hop = jumpClient(config.JumpHost{Host: "bastion.example.com", User: "admin", Port: 2222, Key: "/path/to/bastion"}, cfg)
+	if hop.User != "admin" || hop.Port != 2222 || hop.KeyPath != "/path/to/bastion" {
+		t.Errorf("jumpClient() = %+v, want the jump host's own settings", hop)
+	}
+}
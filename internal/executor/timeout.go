@@ -0,0 +1,50 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/marcelsud/swarmctl/internal/config"
+)
+
+// Timeouts limits how long commands may run. Zero disables a limit.
+type Timeouts struct {
+	// Command limits Run, RunWithStdin, RunContext and WriteFile
+	Command time.Duration
+	// Stream limits RunStream and RunStreamContext
+	Stream time.Duration
+	// Interactive limits RunInteractive and RunInteractiveContext
+	Interactive time.Duration
+}
+
+// TimeoutsFrom returns the timeouts configured in swarm.yaml
+func TimeoutsFrom(cfg config.Timeouts) Timeouts {
+	return Timeouts{
+		Command:     time.Duration(cfg.Command),
+		Stream:      time.Duration(cfg.Stream),
+		Interactive: time.Duration(cfg.Interactive),
+	}
+}
+
+// withTimeout returns a copy of ctx that expires after d, or that is only
+// cancelled with ctx when d is zero
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// contextError returns err, or why ctx stopped the command when it did,
+// naming the timeout when it expired
+func contextError(ctx context.Context, d time.Duration, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	if d > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("command timed out after %s: %w", d, ctx.Err())
+	}
+	return ctx.Err()
+}
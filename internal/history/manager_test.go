@@ -1,6 +1,7 @@
 package history
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"testing"
@@ -55,6 +56,22 @@ func (m *MockExecutor) RunStream(cmd string, stdout, stderr io.Writer) error {
 	return nil
 }
 
+func (m *MockExecutor) RunContext(ctx context.Context, cmd string) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
+func (m *MockExecutor) RunInteractiveContext(ctx context.Context, cmd string) error {
+	return m.RunInteractive(cmd)
+}
+
+func (m *MockExecutor) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	return m.RunStream(cmd, stdout, stderr)
+}
+
+func (m *MockExecutor) RunWithStdin(cmd string, stdin io.Reader) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
 func (m *MockExecutor) WriteFile(path string, content []byte) error {
 	m.writeFiles[path] = content
 	return nil
@@ -0,0 +1,18 @@
+// Package hostdir locates the files swarmctl keeps on compose hosts
+package hostdir
+
+import "path"
+
+// Root is where swarmctl keeps files on compose hosts, one directory per
+// stack
+const Root = "/var/lib/swarmctl"
+
+// Files returns the directory of the files uploaded for a stack
+func Files(stack string) string {
+	return path.Join(Root, stack, "files")
+}
+
+// Secrets returns the directory of the compose-mode secrets of a stack
+func Secrets(stack string) string {
+	return path.Join(Root, stack, "secrets")
+}
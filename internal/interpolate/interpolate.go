@@ -0,0 +1,213 @@
+package interpolate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LookupFunc returns the value of a variable and whether it is set
+type LookupFunc func(name string) (string, bool)
+
+// MissingError is returned when a required variable (${VAR:?err} or
+// ${VAR?err}) is not set
+type MissingError struct {
+	Name    string
+	Message string
+}
+
+func (e *MissingError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("required variable %s is not set: %s", e.Name, e.Message)
+	}
+	return fmt.Sprintf("required variable %s is not set", e.Name)
+}
+
+// Expand substitutes variables in s following docker compose rules:
+//
+//	$VAR, ${VAR}        value of VAR, empty if unset
+//	${VAR:-default}     default if VAR is unset or empty
+//	${VAR-default}      default if VAR is unset
+//	${VAR:?err}         error if VAR is unset or empty
+//	${VAR?err}          error if VAR is unset
+//	${VAR:+alt}         alt if VAR is set and not empty
+//	${VAR+alt}          alt if VAR is set
+//	$$                  a literal $
+//
+// Defaults and alternatives may themselves contain variables.
+func Expand(s string, lookup LookupFunc) (string, error) {
+	e := &Expander{Lookup: lookup}
+	return e.Expand(s)
+}
+
+// ExpandBraced is like Expand but only substitutes the ${...} forms,
+// leaving bare $VAR and $$ untouched, so values that already contain a
+// literal $ keep it. $${ escapes a literal ${.
+func ExpandBraced(s string, lookup LookupFunc) (string, error) {
+	e := &Expander{Lookup: lookup, BracedOnly: true}
+	return e.Expand(s)
+}
+
+// Expander expands strings like Expand and keeps track of variables
+// that were referenced without a default and are not set
+type Expander struct {
+	Lookup LookupFunc
+	// BracedOnly leaves bare $VAR and $$ untouched, as ExpandBraced does
+	BracedOnly bool
+	// Unset lists unset variables that expanded to an empty string
+	Unset []string
+}
+
+// Expand substitutes variables in s
+func (e *Expander) Expand(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '$' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+
+		next := s[i+1]
+		switch {
+		case next == '$' && e.BracedOnly:
+			// Only $${ is an escape; its { is written by the next iteration
+			b.WriteByte('$')
+			if i+2 >= len(s) || s[i+2] != '{' {
+				b.WriteByte('$')
+			}
+			i++
+
+		case next == '$':
+			b.WriteByte('$')
+			i++
+
+		case next == '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("invalid interpolation format in %q: missing closing brace", s)
+			}
+			value, err := e.substitute(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end
+
+		case !e.BracedOnly && isNameStart(next):
+			j := i + 1
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			b.WriteString(e.value(s[i+1 : j]))
+			i = j - 1
+
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// value looks up a variable referenced without a default
+func (e *Expander) value(name string) string {
+	value, set := e.Lookup(name)
+	if !set {
+		e.markUnset(name)
+	}
+	return value
+}
+
+func (e *Expander) markUnset(name string) {
+	for _, n := range e.Unset {
+		if n == name {
+			return
+		}
+	}
+	e.Unset = append(e.Unset, name)
+}
+
+// substitute resolves the contents of a ${...} expression
+func (e *Expander) substitute(expr string) (string, error) {
+	n := 0
+	for n < len(expr) && isNameChar(expr[n]) {
+		n++
+	}
+	name := expr[:n]
+	if name == "" || !isNameStart(name[0]) {
+		return "", fmt.Errorf("invalid interpolation format for ${%s}", expr)
+	}
+
+	rest := expr[n:]
+	if rest == "" {
+		return e.value(name), nil
+	}
+	value, set := e.Lookup(name)
+
+	// A leading colon also treats an empty value as unset
+	colon := strings.HasPrefix(rest, ":")
+	if colon {
+		rest = rest[1:]
+	}
+	if rest == "" {
+		return "", fmt.Errorf("invalid interpolation format for ${%s}", expr)
+	}
+
+	op, arg := rest[0], rest[1:]
+	present := set && (!colon || value != "")
+
+	switch op {
+	case '-':
+		if present {
+			return value, nil
+		}
+		return e.Expand(arg)
+	case '?':
+		if present {
+			return value, nil
+		}
+		msg, err := e.Expand(arg)
+		if err != nil {
+			return "", err
+		}
+		return "", &MissingError{Name: name, Message: msg}
+	case '+':
+		if present {
+			return e.Expand(arg)
+		}
+		return "", nil
+	}
+
+	return "", fmt.Errorf("invalid interpolation format for ${%s}", expr)
+}
+
+// closingBrace returns the index of the brace closing a ${ that starts
+// just before from, honouring nested ${...}
+func closingBrace(s string, from int) int {
+	depth := 1
+	for i := from; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
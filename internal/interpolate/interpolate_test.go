@@ -0,0 +1,132 @@
+package interpolate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testLookup(vars map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestExpand(t *testing.T) {
+	lookup := testLookup(map[string]string{
+		"HOST":  "example.com",
+		"EMPTY": "",
+		"TAG":   "v1.2.3",
+	})
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"${HOST}", "example.com"},
+		{"$HOST", "example.com"},
+		{"app:${TAG}", "app:v1.2.3"},
+		{"${MISSING}", ""},
+		{"${MISSING:-fallback}", "fallback"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${EMPTY-fallback}", ""},
+		{"${MISSING-fallback}", "fallback"},
+		{"${HOST:+alt}", "alt"},
+		{"${EMPTY:+alt}", ""},
+		{"${EMPTY+alt}", "alt"},
+		{"${MISSING:-${HOST}}", "example.com"},
+		{"$$HOST", "$HOST"},
+		{"price: 5$", "price: 5$"},
+		{"$1", "$1"},
+	}
+
+	for _, tt := range tests {
+		got, err := Expand(tt.in, lookup)
+		if err != nil {
+			t.Errorf("Expand(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpand_Required(t *testing.T) {
+	lookup := testLookup(map[string]string{"EMPTY": "", "SET": "x"})
+
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{"${SET:?must be set}", false},
+		{"${MISSING:?must be set}", true},
+		{"${EMPTY:?must be set}", true},
+		{"${EMPTY?must be set}", false},
+		{"${MISSING?must be set}", true},
+	}
+
+	for _, tt := range tests {
+		_, err := Expand(tt.in, lookup)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Expand(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+
+		var missing *MissingError
+		if tt.wantErr && !errors.As(err, &missing) {
+			t.Errorf("Expand(%q) error should be MissingError, got %T", tt.in, err)
+		}
+		if missing != nil && missing.Message != "must be set" {
+			t.Errorf("MissingError.Message = %q", missing.Message)
+		}
+	}
+}
+
+func TestExpand_Invalid(t *testing.T) {
+	lookup := testLookup(nil)
+
+	for _, in := range []string{"${HOST", "${}", "${1ABC}", "${HOST:}", "${HOST:!x}"} {
+		if _, err := Expand(in, lookup); err == nil {
+			t.Errorf("Expand(%q) should fail", in)
+		}
+	}
+}
+
+func TestExpandBraced(t *testing.T) {
+	lookup := testLookup(map[string]string{"HOST": "example.com"})
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"p@$HOST-${HOST}-$$", "p@$HOST-example.com-$$"},
+		{"$$HOST", "$$HOST"},
+		{"$${HOST}", "${HOST}"},
+		{"$$$${HOST}", "$$${HOST}"},
+	}
+	for _, tt := range tests {
+		got, err := ExpandBraced(tt.in, lookup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("ExpandBraced(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpander_Unset(t *testing.T) {
+	e := &Expander{Lookup: testLookup(map[string]string{"SET": "x"})}
+
+	for _, in := range []string{"$SET", "${TAG}", "$TAG", "${HOST:-default}", "${PORT-80}", "${MODE:+on}", "$REGION"} {
+		if _, err := e.Expand(in); err != nil {
+			t.Fatalf("Expand(%q) error = %v", in, err)
+		}
+	}
+
+	if strings.Join(e.Unset, ",") != "TAG,REGION" {
+		t.Errorf("Unset = %v, want [TAG REGION]", e.Unset)
+	}
+}
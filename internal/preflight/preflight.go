@@ -0,0 +1,350 @@
+package preflight
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/marcelsud/swarmctl/internal/compose"
+	"github.com/marcelsud/swarmctl/internal/config"
+	"github.com/marcelsud/swarmctl/internal/executor"
+)
+
+// Result is the outcome of a single check
+type Result struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// Failed reports whether any check failed
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if !r.OK {
+			return true
+		}
+	}
+	return false
+}
+
+// Checker verifies that a deploy can succeed before anything is changed
+type Checker struct {
+	exec executor.Executor
+	cfg  *config.Config
+
+	// Secrets are the secrets from swarm.yaml that the deploy will create
+	Secrets []string
+	// SkipRegistries skips the registry credentials check, for stacks
+	// that only pull public images
+	SkipRegistries bool
+}
+
+// NewChecker creates a new Checker
+func NewChecker(exec executor.Executor, cfg *config.Config) *Checker {
+	return &Checker{
+		exec: exec,
+		cfg:  cfg,
+	}
+}
+
+// Run checks the deploy target against the compose content that will be
+// deployed. Every check runs, so all problems are reported in one pass.
+func (c *Checker) Run(composeContent []byte) ([]Result, error) {
+	project, err := compose.Parse(composeContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	if c.cfg.Mode == config.ModeCompose {
+		results = append(results, c.checkCompose())
+	} else {
+		results = append(results, c.checkManager(), c.checkNetwork())
+		results = append(results, c.checkExternal("secret", project.Secrets, usedSecrets(project), c.willCreateSecret)...)
+		results = append(results, c.checkExternal("config", project.Configs, usedConfigs(project), c.willCreateConfig)...)
+	}
+	results = append(results, c.checkExternal("network", project.Networks, usedNetworks(project), nil)...)
+	if !c.SkipRegistries {
+		results = append(results, c.checkRegistries(project)...)
+	}
+	return results, nil
+}
+
+// checkManager checks that the node is a reachable Swarm manager
+func (c *Checker) checkManager() Result {
+	r := Result{Name: "Swarm manager"}
+
+	result, err := c.exec.Run("docker info --format '{{.Swarm.LocalNodeState}} {{.Swarm.ControlAvailable}}'")
+	if err != nil || result.ExitCode != 0 {
+		r.Detail = "docker is not reachable"
+		if err == nil && result.Stderr != "" {
+			r.Detail += ": " + strings.TrimSpace(result.Stderr)
+		}
+		return r
+	}
+
+	switch fields := strings.Fields(result.Stdout); {
+	case len(fields) == 0 || fields[0] != "active":
+		r.Detail = "Swarm is not initialized; run swarmctl setup"
+	case len(fields) < 2 || fields[1] != "true":
+		r.Detail = "node is not a Swarm manager"
+	default:
+		r.OK = true
+	}
+	return r
+}
+
+// checkCompose checks that docker compose is available
+func (c *Checker) checkCompose() Result {
+	r := Result{Name: "docker compose"}
+
+	result, err := c.exec.Run("docker compose version --short")
+	if err != nil || result.ExitCode != 0 {
+		r.Detail = "docker compose plugin not found; run swarmctl setup"
+		return r
+	}
+	r.OK = true
+	r.Detail = strings.TrimSpace(result.Stdout)
+	return r
+}
+
+// networkName returns the overlay network created by setup
+func (c *Checker) networkName() string {
+	return c.cfg.Stack + "-network"
+}
+
+// checkNetwork checks that the overlay network created by setup exists
+func (c *Checker) checkNetwork() Result {
+	name := c.networkName()
+	r := Result{Name: "Network " + name}
+
+	exists, err := c.exists("network", name)
+	switch {
+	case err != nil:
+		r.Detail = err.Error()
+	case !exists:
+		r.Detail = "not found; run swarmctl setup"
+	default:
+		r.OK = true
+	}
+	return r
+}
+
+// checkExternal checks that the external resources services use exist,
+// or will be created by the deploy
+func (c *Checker) checkExternal(kind string, resources map[string]*compose.Resource, used []string, willCreate func(key string) bool) []Result {
+	var results []Result
+	for _, key := range used {
+		resource, ok := resources[key]
+		if !ok || !resource.External {
+			continue
+		}
+
+		name := key
+		if resource.Name != "" {
+			name = resource.Name
+		}
+		// Already reported by checkNetwork
+		if kind == "network" && c.cfg.Mode != config.ModeCompose && name == c.networkName() {
+			continue
+		}
+		r := Result{Name: fmt.Sprintf("External %s %s", kind, name)}
+
+		if willCreate != nil && willCreate(key) {
+			r.OK = true
+			r.Detail = "will be created"
+			results = append(results, r)
+			continue
+		}
+
+		exists, err := c.exists(kind, name)
+		switch {
+		case err != nil:
+			r.Detail = err.Error()
+		case !exists:
+			r.Detail = "not found"
+		default:
+			r.OK = true
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// exists reports whether a Docker object of the given kind exists
+func (c *Checker) exists(kind, name string) (bool, error) {
+	result, err := c.exec.Run(fmt.Sprintf("docker %s inspect --format '{{.ID}}' %s", kind, shellquote.Join(name)))
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s: %w", kind, err)
+	}
+	return result.ExitCode == 0, nil
+}
+
+// willCreateSecret reports whether a compose secret definition is one of
+// the secrets the deploy creates, matched like secrets.Manager.UseVersions
+func (c *Checker) willCreateSecret(key string) bool {
+	for _, name := range c.Secrets {
+		lower := strings.ToLower(name)
+		if key == c.cfg.Stack+"_"+lower || key == lower || key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// willCreateConfig reports whether a compose config definition is one of
+// the configs from swarm.yaml
+func (c *Checker) willCreateConfig(key string) bool {
+	_, ok := c.cfg.Configs[key]
+	return ok
+}
+
+// checkRegistries checks that images pulled from a registry host have
+// credentials, either from registry in swarm.yaml or from a docker login
+// already done on the server
+func (c *Checker) checkRegistries(project *compose.Project) []Result {
+	hosts := make(map[string][]string)
+	for service, image := range project.Images() {
+		if host := registryHost(image); host != "" {
+			hosts[host] = append(hosts[host], service)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(hosts))
+	for host := range hosts {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+
+	registry := c.cfg.Registry
+	logins := c.registryLogins()
+
+	var results []Result
+	for _, host := range names {
+		r := Result{Name: "Registry credentials for " + host}
+		configured := urlHost(registry.URL) == host
+		switch {
+		case configured && registry.Username != "" && registry.Password == "":
+			r.Detail = "SWARMCTL_REGISTRY_PASSWORD is not set"
+		case configured && registry.Username != "":
+			r.OK = true
+		case logins[host]:
+			r.OK = true
+			r.Detail = "logged in on the server"
+		case configured:
+			r.Detail = "registry.username is not set"
+		default:
+			sort.Strings(hosts[host])
+			r.Detail = fmt.Sprintf("registry.url is not %s and the server is not logged in (used by %s); use --skip-registry-check for public images", host, strings.Join(hosts[host], ", "))
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// registryLogins returns the registry hosts the server has credentials
+// for in ~/.docker/config.json, from docker login or a credential helper.
+// A missing or unreadable file gives none.
+func (c *Checker) registryLogins() map[string]bool {
+	logins := make(map[string]bool)
+
+	result, err := c.exec.Run("cat ~/.docker/config.json")
+	if err != nil || result.ExitCode != 0 {
+		return logins
+	}
+
+	var dockerConfig struct {
+		Auths       map[string]json.RawMessage `json:"auths"`
+		CredHelpers map[string]string          `json:"credHelpers"`
+	}
+	if err := json.Unmarshal([]byte(result.Stdout), &dockerConfig); err != nil {
+		return logins
+	}
+	for server := range dockerConfig.Auths {
+		logins[urlHost(server)] = true
+	}
+	for server := range dockerConfig.CredHelpers {
+		logins[urlHost(server)] = true
+	}
+	return logins
+}
+
+// registryHost returns the registry host of an image reference, or ""
+// for Docker Hub and local registries, which need no credentials
+func registryHost(image string) string {
+	i := strings.IndexByte(image, '/')
+	if i < 0 {
+		return ""
+	}
+	host := image[:i]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return ""
+	}
+
+	switch strings.Split(host, ":")[0] {
+	case "docker.io", "index.docker.io", "registry-1.docker.io", "localhost", "127.0.0.1":
+		return ""
+	}
+	return host
+}
+
+// urlHost returns the host of a registry URL such as https://ghcr.io/
+func urlHost(url string) string {
+	url = strings.TrimPrefix(url, "https://")
+	url = strings.TrimPrefix(url, "http://")
+	host, _, _ := strings.Cut(url, "/")
+	return host
+}
+
+// usedSecrets returns the secret definitions referenced by services
+func usedSecrets(p *compose.Project) []string {
+	return usedKeys(p, func(svc *compose.Service) []string {
+		return referenceSources(svc.Secrets)
+	})
+}
+
+// usedConfigs returns the config definitions referenced by services
+func usedConfigs(p *compose.Project) []string {
+	return usedKeys(p, func(svc *compose.Service) []string {
+		return referenceSources(svc.Configs)
+	})
+}
+
+// usedNetworks returns the network definitions referenced by services
+func usedNetworks(p *compose.Project) []string {
+	return usedKeys(p, func(svc *compose.Service) []string {
+		keys := make([]string, 0, len(svc.Networks))
+		for name := range svc.Networks {
+			keys = append(keys, name)
+		}
+		return keys
+	})
+}
+
+func usedKeys(p *compose.Project, keys func(*compose.Service) []string) []string {
+	seen := make(map[string]bool)
+	var used []string
+	for _, name := range p.ServiceNames() {
+		for _, key := range keys(p.Services[name]) {
+			if !seen[key] {
+				seen[key] = true
+				used = append(used, key)
+			}
+		}
+	}
+	sort.Strings(used)
+	return used
+}
+
+func referenceSources(refs []compose.FileReference) []string {
+	sources := make([]string, len(refs))
+	for i, ref := range refs {
+		sources[i] = ref.Source
+	}
+	return sources
+}
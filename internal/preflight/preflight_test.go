@@ -0,0 +1,327 @@
+package preflight
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/marcelsud/swarmctl/internal/config"
+	"github.com/marcelsud/swarmctl/internal/executor"
+)
+
+// PreflightMockExecutor for testing preflight checks
+type PreflightMockExecutor struct {
+	runCommands []string
+	runResults  map[string]*executor.CommandResult
+}
+
+func NewPreflightMockExecutor() *PreflightMockExecutor {
+	return &PreflightMockExecutor{
+		runResults: make(map[string]*executor.CommandResult),
+	}
+}
+
+func (m *PreflightMockExecutor) Run(cmd string) (*executor.CommandResult, error) {
+	m.runCommands = append(m.runCommands, cmd)
+	if result, exists := m.runResults[cmd]; exists {
+		return result, nil
+	}
+	return &executor.CommandResult{ExitCode: 0}, nil
+}
+
+func (m *PreflightMockExecutor) RunWithStdin(cmd string, stdin io.Reader) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
+func (m *PreflightMockExecutor) RunInteractive(cmd string) error {
+	m.runCommands = append(m.runCommands, cmd)
+	return nil
+}
+
+func (m *PreflightMockExecutor) RunStream(cmd string, stdout, stderr io.Writer) error {
+	m.runCommands = append(m.runCommands, cmd)
+	return nil
+}
+
+func (m *PreflightMockExecutor) RunContext(ctx context.Context, cmd string) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
+func (m *PreflightMockExecutor) RunInteractiveContext(ctx context.Context, cmd string) error {
+	return m.RunInteractive(cmd)
+}
+
+func (m *PreflightMockExecutor) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	return m.RunStream(cmd, stdout, stderr)
+}
+
+func (m *PreflightMockExecutor) WriteFile(path string, content []byte) error {
+	return nil
+}
+
+func (m *PreflightMockExecutor) Close() error {
+	return nil
+}
+
+func (m *PreflightMockExecutor) IsLocal() bool {
+	return false
+}
+
+func (m *PreflightMockExecutor) SetVerbose(v bool) {}
+
+func (m *PreflightMockExecutor) SetRunResult(cmd string, result *executor.CommandResult) {
+	m.runResults[cmd] = result
+}
+
+const managerInfo = "docker info --format '{{.Swarm.LocalNodeState}} {{.Swarm.ControlAvailable}}'"
+
+const preflightCompose = `services:
+  web:
+    image: ghcr.io/acme/web:1.2
+    networks:
+      - myapp-network
+      - shared
+    secrets:
+      - myapp_database_url
+      - legacy_token
+    configs:
+      - nginx_conf
+  redis:
+    image: redis:7
+secrets:
+  myapp_database_url:
+    external: true
+  legacy_token:
+    external: true
+    name: myapp_legacy_token
+  unused:
+    external: true
+configs:
+  nginx_conf:
+    external: true
+networks:
+  myapp-network:
+    external: true
+  shared:
+    external: true
+`
+
+func newPreflightConfig() *config.Config {
+	return &config.Config{
+		Stack:   "myapp",
+		Mode:    config.ModeSwarm,
+		Configs: map[string]string{"nginx_conf": "nginx.conf"},
+		Registry: config.Registry{
+			URL:      "https://ghcr.io",
+			Username: "deploy",
+			Password: "token",
+		},
+	}
+}
+
+func resultsByName(results []Result) map[string]Result {
+	byName := make(map[string]Result, len(results))
+	for _, r := range results {
+		byName[r.Name] = r
+	}
+	return byName
+}
+
+func TestRunAllPass(t *testing.T) {
+	mock := NewPreflightMockExecutor()
+	mock.SetRunResult(managerInfo, &executor.CommandResult{Stdout: "active true\n"})
+
+	c := NewChecker(mock, newPreflightConfig())
+	c.Secrets = []string{"DATABASE_URL"}
+	results, err := c.Run([]byte(preflightCompose))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if Failed(results) {
+		t.Fatalf("expected all checks to pass, got %+v", results)
+	}
+
+	byName := resultsByName(results)
+	for _, name := range []string{
+		"Swarm manager",
+		"Network myapp-network",
+		"External secret myapp_database_url",
+		"External secret myapp_legacy_token",
+		"External config nginx_conf",
+		"External network shared",
+		"Registry credentials for ghcr.io",
+	} {
+		if _, ok := byName[name]; !ok {
+			t.Errorf("missing check %q in %+v", name, results)
+		}
+	}
+	if len(results) != 7 {
+		t.Errorf("expected 7 checks, got %+v", results)
+	}
+	if byName["External secret myapp_database_url"].Detail != "will be created" {
+		t.Errorf("secret from swarm.yaml should be created, got %+v", byName["External secret myapp_database_url"])
+	}
+
+	for _, cmd := range mock.runCommands {
+		if strings.Contains(cmd, "myapp_database_url") || strings.Contains(cmd, "unused") {
+			t.Errorf("unexpected lookup %s", cmd)
+		}
+	}
+}
+
+func TestRunReportsEveryFailure(t *testing.T) {
+	mock := NewPreflightMockExecutor()
+	mock.SetRunResult(managerInfo, &executor.CommandResult{Stdout: "active false\n"})
+	mock.SetRunResult("docker network inspect --format '{{.ID}}' myapp-network", &executor.CommandResult{ExitCode: 1})
+	mock.SetRunResult("docker secret inspect --format '{{.ID}}' myapp_legacy_token", &executor.CommandResult{ExitCode: 1})
+	mock.SetRunResult("docker secret inspect --format '{{.ID}}' myapp_database_url", &executor.CommandResult{ExitCode: 1})
+
+	cfg := newPreflightConfig()
+	cfg.Registry = config.Registry{}
+	results, err := NewChecker(mock, cfg).Run([]byte(preflightCompose))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !Failed(results) {
+		t.Fatal("expected failures")
+	}
+
+	byName := resultsByName(results)
+	failures := map[string]string{
+		"Swarm manager":                      "not a Swarm manager",
+		"Network myapp-network":              "swarmctl setup",
+		"External secret myapp_database_url": "not found",
+		"External secret myapp_legacy_token": "not found",
+		"Registry credentials for ghcr.io":   "used by web",
+	}
+	for name, detail := range failures {
+		r := byName[name]
+		if r.OK || !strings.Contains(r.Detail, detail) {
+			t.Errorf("%s: expected failure containing %q, got %+v", name, detail, r)
+		}
+	}
+	if !byName["External network shared"].OK {
+		t.Error("existing network should pass")
+	}
+}
+
+func TestRunComposeMode(t *testing.T) {
+	mock := NewPreflightMockExecutor()
+	mock.SetRunResult("docker compose version --short", &executor.CommandResult{Stdout: "2.27.0\n"})
+
+	cfg := newPreflightConfig()
+	cfg.Mode = config.ModeCompose
+	results, err := NewChecker(mock, cfg).Run([]byte(preflightCompose))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byName := resultsByName(results)
+	if r := byName["docker compose"]; !r.OK || r.Detail != "2.27.0" {
+		t.Errorf("unexpected compose check %+v", r)
+	}
+	for _, r := range results {
+		if strings.HasPrefix(r.Name, "External secret") || r.Name == "Swarm manager" {
+			t.Errorf("swarm check %q should not run in compose mode", r.Name)
+		}
+	}
+	if _, ok := byName["External network myapp-network"]; !ok {
+		t.Error("external networks should be checked in compose mode")
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                         "",
+		"redis:7":                       "",
+		"library/nginx":                 "",
+		"docker.io/library/nginx":       "",
+		"localhost:5000/app":            "",
+		"ghcr.io/acme/web:1.2":          "ghcr.io",
+		"registry.example.com:5000/app": "registry.example.com:5000",
+		"123.dkr.ecr.us-east-1.amazonaws.com/app@sha256:abc": "123.dkr.ecr.us-east-1.amazonaws.com",
+	}
+	for image, want := range tests {
+		if got := registryHost(image); got != want {
+			t.Errorf("registryHost(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestRegistryPasswordMissing(t *testing.T) {
+	mock := NewPreflightMockExecutor()
+	mock.SetRunResult(managerInfo, &executor.CommandResult{Stdout: "active true\n"})
+
+	cfg := newPreflightConfig()
+	cfg.Registry.Password = ""
+	results, err := NewChecker(mock, cfg).Run([]byte("services:\n  web:\n    image: ghcr.io/acme/web\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := resultsByName(results)["Registry credentials for ghcr.io"]
+	if r.OK || !strings.Contains(r.Detail, "SWARMCTL_REGISTRY_PASSWORD") {
+		t.Errorf("expected missing password failure, got %+v", r)
+	}
+}
+
+func TestRegistryWithoutCredentials(t *testing.T) {
+	compose := []byte(`services:
+  web:
+    image: ghcr.io/acme/web
+  api:
+    image: quay.io/acme/api
+  worker:
+    image: 123.dkr.ecr.us-east-1.amazonaws.com/worker
+`)
+
+	mock := NewPreflightMockExecutor()
+	mock.SetRunResult(managerInfo, &executor.CommandResult{Stdout: "active true\n"})
+	mock.SetRunResult("cat ~/.docker/config.json", &executor.CommandResult{
+		Stdout: `{"auths": {"https://ghcr.io": {}}, "credHelpers": {"123.dkr.ecr.us-east-1.amazonaws.com": "ecr-login"}}`,
+	})
+
+	cfg := newPreflightConfig()
+	cfg.Registry = config.Registry{}
+	results, err := NewChecker(mock, cfg).Run(compose)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !Failed(results) {
+		t.Error("missing credentials should fail the deploy")
+	}
+
+	byName := resultsByName(results)
+	for _, host := range []string{"ghcr.io", "123.dkr.ecr.us-east-1.amazonaws.com"} {
+		if r := byName["Registry credentials for "+host]; !r.OK || r.Detail != "logged in on the server" {
+			t.Errorf("%s: expected login from ~/.docker/config.json, got %+v", host, r)
+		}
+	}
+	if r := byName["Registry credentials for quay.io"]; r.OK || !strings.Contains(r.Detail, "used by api") {
+		t.Errorf("quay.io: expected a failure, got %+v", r)
+	}
+}
+
+func TestSkipRegistries(t *testing.T) {
+	mock := NewPreflightMockExecutor()
+	mock.SetRunResult(managerInfo, &executor.CommandResult{Stdout: "active true\n"})
+
+	cfg := newPreflightConfig()
+	cfg.Registry = config.Registry{}
+	checker := NewChecker(mock, cfg)
+	checker.SkipRegistries = true
+	results, err := checker.Run([]byte("services:\n  web:\n    image: quay.io/acme/web\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := resultsByName(results)["Registry credentials for quay.io"]; ok {
+		t.Error("registry check should be skipped")
+	}
+	for _, cmd := range mock.runCommands {
+		if strings.Contains(cmd, ".docker/config.json") {
+			t.Error("server logins should not be read")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"sort"
+	"strings"
+)
+
+// DiffStatus describes how a secret differs between local values and the swarm
+type DiffStatus string
+
+const (
+	// StatusNew is a secret with a local value and no version on the swarm
+	StatusNew DiffStatus = "new"
+	// StatusChanged is a secret whose local value differs from every version
+	StatusChanged DiffStatus = "changed"
+	// StatusUnchanged is a secret whose local value is already on the swarm
+	StatusUnchanged DiffStatus = "unchanged"
+	// StatusMissing is a secret listed in swarm.yaml without a local value
+	StatusMissing DiffStatus = "missing"
+	// StatusUnlisted is a secret on the swarm no longer listed in swarm.yaml
+	StatusUnlisted DiffStatus = "unlisted"
+)
+
+// Difference is the status of a single secret
+type Difference struct {
+	Name     string
+	Status   DiffStatus
+	Versions []string
+}
+
+// Diff compares the local secret values with the swarm. names are the
+// secrets listed in swarm.yaml; local holds the ones that have a value.
+// Secrets listed in swarm.yaml come first, in order, followed by unlisted
+// secrets sorted by name.
+func (m *Manager) Diff(names []string, local []Secret) ([]Difference, error) {
+	values := make(map[string]string, len(local))
+	for _, s := range local {
+		values[s.Name] = s.Value
+	}
+
+	var diffs []Difference
+	listed := make(map[string]bool, len(names))
+	for _, name := range names {
+		listed[strings.ToLower(name)] = true
+
+		value, ok := values[name]
+		if !ok {
+			versions, err := m.store.Versions(name)
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, Difference{Name: name, Status: StatusMissing, Versions: versions})
+			continue
+		}
+
+		versions, existing, err := m.findVersion(name, value)
+		if err != nil {
+			return nil, err
+		}
+
+		status := StatusChanged
+		switch {
+		case existing != "":
+			status = StatusUnchanged
+		case len(versions) == 0:
+			status = StatusNew
+		}
+		diffs = append(diffs, Difference{Name: name, Status: status, Versions: versions})
+	}
+
+	remote, err := m.list(names)
+	if err != nil {
+		return nil, err
+	}
+
+	unlisted := make(map[string][]string)
+	for _, secret := range remote {
+		base, ok := m.store.BaseName(secret)
+		if !ok || listed[base] {
+			continue
+		}
+		unlisted[base] = append(unlisted[base], secret)
+	}
+
+	bases := make([]string, 0, len(unlisted))
+	for base := range unlisted {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+	for _, base := range bases {
+		diffs = append(diffs, Difference{Name: base, Status: StatusUnlisted, Versions: unlisted[base]})
+	}
+
+	return diffs, nil
+}
@@ -0,0 +1,209 @@
+package secrets
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/marcelsud/swarmctl/internal/compose"
+	"github.com/marcelsud/swarmctl/internal/executor"
+	"github.com/marcelsud/swarmctl/internal/hostdir"
+	"github.com/marcelsud/swarmctl/internal/versioned"
+)
+
+// saltFile holds the salt of the fingerprints in version names, so the
+// version of an unchanged value can be found without reading files back
+const saltFile = ".salt"
+
+// FileManager stores secrets as files on compose hosts, where Docker
+// secrets are not available. Each version is written read-only to
+// /var/lib/swarmctl/<stack>/secrets/<stack>_<name>_<hash> and mounted by
+// docker compose through a file: secret.
+type FileManager struct {
+	exec      executor.Executor
+	stackName string
+	store     *versioned.Store
+	dir       string
+	salt      string
+}
+
+// NewFileManager creates a new file-based secrets manager
+func NewFileManager(exec executor.Executor, stackName string) *FileManager {
+	return &FileManager{
+		exec:      exec,
+		stackName: stackName,
+		store:     versioned.NewStore(exec, stackName, versioned.Secret),
+		dir:       hostdir.Secrets(stackName),
+	}
+}
+
+// Path returns the host path of a secret version
+func (m *FileManager) Path(versionedName string) string {
+	return path.Join(m.dir, versionedName)
+}
+
+// Create writes a version of a secret with mode 0400 unless one with the
+// same value exists
+func (m *FileManager) Create(name, value string) (string, bool, error) {
+	salt, err := m.loadSalt()
+	if err != nil {
+		return "", false, err
+	}
+	versionedName := m.store.HashedName(name, fingerprint(salt, value))
+	target := m.Path(versionedName)
+
+	result, err := m.exec.Run(fmt.Sprintf("test -f %s", shellquote.Join(target)))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to check secret: %w", err)
+	}
+	if result.ExitCode == 0 {
+		return versionedName, false, nil
+	}
+
+	// The value goes through stdin so it never shows up in the command
+	// line; the file only becomes visible once complete
+	tmp := path.Join(m.dir, "."+versionedName+".tmp")
+	cmd := fmt.Sprintf("umask 077 && mkdir -p %s && rm -f %s && cat > %s && chmod 0400 %s && mv -f %s %s",
+		shellquote.Join(m.dir), shellquote.Join(tmp), shellquote.Join(tmp), shellquote.Join(tmp), shellquote.Join(tmp), shellquote.Join(target))
+	result, err = m.exec.RunWithStdin(cmd, strings.NewReader(value))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create secret: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return "", false, fmt.Errorf("secret creation failed: %s", result.Stderr)
+	}
+
+	return versionedName, true, nil
+}
+
+// loadSalt reads the stack's fingerprint salt from the host, creating it
+// on first use
+func (m *FileManager) loadSalt() (string, error) {
+	if m.salt != "" {
+		return m.salt, nil
+	}
+
+	saltPath := path.Join(m.dir, saltFile)
+	result, err := m.exec.Run(fmt.Sprintf("cat %s 2>/dev/null", shellquote.Join(saltPath)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secrets salt: %w", err)
+	}
+	if salt := strings.TrimSpace(result.Stdout); result.ExitCode == 0 && salt != "" {
+		m.salt = salt
+		return salt, nil
+	}
+
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+	cmd := fmt.Sprintf("umask 077 && mkdir -p %s && cat > %s", shellquote.Join(m.dir), shellquote.Join(saltPath))
+	result, err = m.exec.RunWithStdin(cmd, strings.NewReader(salt+"\n"))
+	if err != nil {
+		return "", fmt.Errorf("failed to write secrets salt: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return "", fmt.Errorf("failed to write secrets salt: %s", result.Stderr)
+	}
+
+	m.salt = salt
+	return salt, nil
+}
+
+// List lists all secret versions for the stack, sorted by name
+func (m *FileManager) List() ([]string, error) {
+	secrets, err := m.listNewestFirst()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(secrets)
+	return secrets, nil
+}
+
+// listNewestFirst lists secret versions, most recently written first
+func (m *FileManager) listNewestFirst() ([]string, error) {
+	result, err := m.exec.Run(fmt.Sprintf("ls -1t %s 2>/dev/null", shellquote.Join(m.dir)))
+	if err != nil {
+		return nil, err
+	}
+
+	// A missing directory means no secrets yet
+	return strings.Fields(result.Stdout), nil
+}
+
+// versions lists the versions of a secret, most recently written first
+func (m *FileManager) versions(name string) ([]string, error) {
+	all, err := m.listNewestFirst()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, v := range all {
+		if m.store.IsVersion(name, v) {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+// Delete deletes every version of a secret
+func (m *FileManager) Delete(name string) error {
+	versions, err := m.versions(name)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("secret %s not found", name)
+	}
+	return m.remove(versions)
+}
+
+// Prune removes old versions of a secret, keeping current and the most
+// recent other version so a rollback can still mount it
+func (m *FileManager) Prune(name, current string) ([]string, error) {
+	versions, err := m.versions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	kept := false
+	for _, v := range versions {
+		if v == current {
+			continue
+		}
+		if !kept {
+			kept = true
+			continue
+		}
+		if err := m.remove([]string{v}); err != nil {
+			continue
+		}
+		removed = append(removed, v)
+	}
+	return removed, nil
+}
+
+func (m *FileManager) remove(versions []string) error {
+	for _, v := range versions {
+		result, err := m.exec.Run(fmt.Sprintf("rm -f %s", shellquote.Join(m.Path(v))))
+		if err != nil {
+			return fmt.Errorf("failed to delete secret: %w", err)
+		}
+		if result.ExitCode != 0 {
+			return fmt.Errorf("secret deletion failed: %s", result.Stderr)
+		}
+	}
+	return nil
+}
+
+// UseVersions points the top-level compose secrets at the files of the
+// given versions, matching definitions like Manager.UseVersions
+func (m *FileManager) UseVersions(composeContent []byte, versions map[string]string) ([]byte, error) {
+	return useVersions(composeContent, m.stackName, versions, func(versioned string) *compose.Resource {
+		return &compose.Resource{File: m.Path(versioned)}
+	})
+}
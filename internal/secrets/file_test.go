@@ -0,0 +1,178 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marcelsud/swarmctl/internal/config"
+	"github.com/marcelsud/swarmctl/internal/executor"
+)
+
+const testSecretsDir = "/var/lib/swarmctl/myapp/secrets"
+
+func newFileManagerMock(salt string) *SecretsMockExecutor {
+	mock := NewSecretsMockExecutor()
+	mock.SetRunResult("cat "+testSecretsDir+"/.salt 2>/dev/null", &executor.CommandResult{Stdout: salt + "\n"})
+	return mock
+}
+
+func TestFileManagerCreate(t *testing.T) {
+	mock := newFileManagerMock("stack-salt")
+	mock.SetRunResultPrefix("test -f ", &executor.CommandResult{ExitCode: 1})
+	m := NewFileManager(mock, "myapp")
+
+	versionedName, created, err := m.Create("DATABASE_URL", "postgres://it's")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "myapp_database_url_" + fingerprint("stack-salt", "postgres://it's")[:12]
+	if versionedName != want || !created {
+		t.Errorf("expected %s created, got %s, %v", want, versionedName, created)
+	}
+
+	var writeCmd string
+	for cmd, stdin := range mock.stdinInputs {
+		if stdin == "postgres://it's" {
+			writeCmd = cmd
+		}
+	}
+	if writeCmd == "" {
+		t.Fatalf("value should be written through stdin, got %v", mock.stdinInputs)
+	}
+	if strings.Contains(writeCmd, "postgres") {
+		t.Errorf("value should not appear in the command: %s", writeCmd)
+	}
+	for _, want := range []string{"umask 077", "chmod 0400", "mv -f", testSecretsDir + "/" + versionedName} {
+		if !strings.Contains(writeCmd, want) {
+			t.Errorf("expected %q in %s", want, writeCmd)
+		}
+	}
+}
+
+func TestFileManagerCreateUnchanged(t *testing.T) {
+	mock := newFileManagerMock("stack-salt")
+	m := NewFileManager(mock, "myapp")
+
+	want := "myapp_api_key_" + fingerprint("stack-salt", "same")[:12]
+	mock.SetRunResultPrefix("test -f ", &executor.CommandResult{ExitCode: 0})
+
+	versionedName, created, err := m.Create("API_KEY", "same")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if versionedName != want || created {
+		t.Errorf("expected existing %s, got %s, created=%v", want, versionedName, created)
+	}
+	if len(mock.stdinInputs) != 0 {
+		t.Errorf("unchanged secret should not be written, got %v", mock.stdinInputs)
+	}
+}
+
+func TestFileManagerCreatesSalt(t *testing.T) {
+	mock := NewSecretsMockExecutor()
+	mock.SetRunResultPrefix("test -f ", &executor.CommandResult{ExitCode: 1})
+	m := NewFileManager(mock, "myapp")
+
+	if _, _, err := m.Create("API_KEY", "value"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	saltCmd := "umask 077 && mkdir -p " + testSecretsDir + " && cat > " + testSecretsDir + "/.salt"
+	salt := strings.TrimSpace(mock.stdinInputs[saltCmd])
+	if salt == "" {
+		t.Fatalf("expected salt to be written, got %v", mock.stdinInputs)
+	}
+
+	// The salt is read once per manager
+	if _, _, err := m.Create("OTHER", "value"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reads := 0
+	for _, cmd := range mock.runCommands {
+		if strings.HasPrefix(cmd, "cat "+testSecretsDir+"/.salt") {
+			reads++
+		}
+	}
+	if reads != 1 {
+		t.Errorf("expected salt to be read once, got %d", reads)
+	}
+}
+
+func TestFileManagerListAndPrune(t *testing.T) {
+	mock := NewSecretsMockExecutor()
+	mock.SetRunResult("ls -1t "+testSecretsDir+" 2>/dev/null", &executor.CommandResult{
+		Stdout: "myapp_api_key_000000000003\nmyapp_db_000000000009\nmyapp_api_key_000000000002\nmyapp_api_key_000000000001\n",
+	})
+	m := NewFileManager(mock, "myapp")
+
+	list, err := m.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(list, ",") != "myapp_api_key_000000000001,myapp_api_key_000000000002,myapp_api_key_000000000003,myapp_db_000000000009" {
+		t.Errorf("unexpected list %v", list)
+	}
+
+	removed, err := m.Prune("API_KEY", "myapp_api_key_000000000003")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(removed, ",") != "myapp_api_key_000000000001" {
+		t.Errorf("expected only the oldest version removed, got %v", removed)
+	}
+	last := mock.runCommands[len(mock.runCommands)-1]
+	if last != "rm -f "+testSecretsDir+"/myapp_api_key_000000000001" {
+		t.Errorf("unexpected remove command %s", last)
+	}
+}
+
+func TestFileManagerDeleteNotFound(t *testing.T) {
+	m := NewFileManager(NewSecretsMockExecutor(), "myapp")
+	if err := m.Delete("API_KEY"); err == nil {
+		t.Error("expected error for a secret with no versions")
+	}
+}
+
+func TestFileManagerUseVersions(t *testing.T) {
+	m := NewFileManager(NewSecretsMockExecutor(), "myapp")
+	content := []byte(`services:
+  web:
+    image: nginx
+    secrets:
+      - myapp_database_url
+secrets:
+  myapp_database_url:
+    external: true
+  tls_key:
+    file: ./tls.key
+`)
+
+	out, err := m.UseVersions(content, map[string]string{
+		"DATABASE_URL": "myapp_database_url_0123456789ab",
+		"TLS_KEY":      "myapp_tls_key_0123456789ab",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := string(out)
+	if !strings.Contains(got, "file: "+testSecretsDir+"/myapp_database_url_0123456789ab") {
+		t.Errorf("expected file: source for database_url:\n%s", got)
+	}
+	if strings.Contains(got, "external") {
+		t.Errorf("external should be dropped in compose mode:\n%s", got)
+	}
+	if !strings.Contains(got, "file: ./tls.key") {
+		t.Errorf("file: definitions should be left alone:\n%s", got)
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	mock := NewSecretsMockExecutor()
+	if _, ok := New(&config.Config{Stack: "myapp", Mode: config.ModeCompose}, mock).(*FileManager); !ok {
+		t.Error("expected FileManager in compose mode")
+	}
+	if _, ok := New(&config.Config{Stack: "myapp", Mode: config.ModeSwarm}, mock).(*Manager); !ok {
+		t.Error("expected Manager in swarm mode")
+	}
+}
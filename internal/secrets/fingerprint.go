@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// Labels set on every secret version so a value can be compared with the
+// one on the swarm without reading it back
+const (
+	saltLabel        = "swarmctl.salt"
+	fingerprintLabel = "swarmctl.fingerprint"
+)
+
+// stackLabel marks the secret versions of a stack. Names can't tell stacks
+// apart: myapp_staging_db_url_<hash> may be db_url of myapp_staging or
+// staging_db_url of myapp.
+const stackLabel = "swarmctl.stack"
+
+// fingerprint returns the HMAC-SHA256 of value keyed by salt. A salted HMAC
+// keeps low-entropy values from being recovered with precomputed tables.
+func fingerprint(salt, value string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newSalt returns a random salt for a new secret version
+func newSalt() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// versionLabels holds the fingerprint labels of a secret version
+type versionLabels struct {
+	salt        string
+	fingerprint string
+}
+
+// matches reports whether the version holds value
+func (l versionLabels) matches(value string) bool {
+	if l.salt == "" || l.fingerprint == "" {
+		return false
+	}
+	return hmac.Equal([]byte(l.fingerprint), []byte(fingerprint(l.salt, value)))
+}
+
+// inspectLabels reads the fingerprint labels of secret versions
+func (m *Manager) inspectLabels(versions []string) (map[string]versionLabels, error) {
+	labels := make(map[string]versionLabels, len(versions))
+	if len(versions) == 0 {
+		return labels, nil
+	}
+
+	format := fmt.Sprintf(`{{.Spec.Name}} {{index .Spec.Labels %q}} {{index .Spec.Labels %q}}`, saltLabel, fingerprintLabel)
+	cmd := fmt.Sprintf("docker secret inspect --format %s %s", shellquote.Join(format), shellquote.Join(versions...))
+	result, err := m.exec.Run(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("failed to inspect secrets: %s", result.Stderr)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(result.Stdout), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 {
+			labels[fields[0]] = versionLabels{salt: fields[1], fingerprint: fields[2]}
+		}
+	}
+	return labels, nil
+}
+
+// findVersion returns the existing versions of a secret and the one
+// holding value, if any
+func (m *Manager) findVersion(name, value string) ([]string, string, error) {
+	versions, err := m.store.Versions(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	labels, err := m.inspectLabels(versions)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, v := range versions {
+		if labels[v].matches(value) {
+			return versions, v, nil
+		}
+	}
+	return versions, "", nil
+}
@@ -1,18 +1,58 @@
 package secrets
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"strings"
 
+	"github.com/kballard/go-shellquote"
+	"github.com/marcelsud/swarmctl/internal/compose"
+	"github.com/marcelsud/swarmctl/internal/config"
 	"github.com/marcelsud/swarmctl/internal/executor"
+	"github.com/marcelsud/swarmctl/internal/versioned"
 )
 
+// Backend stores secret versions on the deploy target
+type Backend interface {
+	// Create stores a version of a secret unless one with the same value
+	// exists. It returns the versioned name and whether it was created.
+	Create(name, value string) (string, bool, error)
+
+	// List lists all secret versions for the stack
+	List() ([]string, error)
+
+	// Delete deletes every version of a secret
+	Delete(name string) error
+
+	// Prune removes old versions of a secret no longer needed after a
+	// deploy, keeping current and the previous version for rollback. It
+	// returns the removed versions.
+	Prune(name, current string) ([]string, error)
+
+	// UseVersions points the compose secrets at the given versions
+	UseVersions(composeContent []byte, versions map[string]string) ([]byte, error)
+}
+
+// New creates the Backend for the deployment mode: Docker secrets in
+// swarm mode, files on the host in compose mode
+func New(cfg *config.Config, exec executor.Executor) Backend {
+	if cfg.Mode == config.ModeCompose {
+		return NewFileManager(exec, cfg.Stack)
+	}
+	m := NewManager(exec, cfg.Stack)
+	m.Known = cfg.Secrets.Names()
+	return m
+}
+
 // Manager handles Docker Swarm secrets
 type Manager struct {
+	// Known are the secrets listed in swarm.yaml. Secrets created before
+	// versions were labelled with their stack are only listed for these
+	// names.
+	Known []string
+
 	exec      executor.Executor
 	stackName string
+	store     *versioned.Store
 }
 
 // NewManager creates a new secrets manager
@@ -20,6 +60,7 @@ func NewManager(exec executor.Executor, stackName string) *Manager {
 	return &Manager{
 		exec:      exec,
 		stackName: stackName,
+		store:     versioned.NewStore(exec, stackName, versioned.Secret),
 	}
 }
 
@@ -27,121 +68,165 @@ func NewManager(exec executor.Executor, stackName string) *Manager {
 type Secret struct {
 	Name  string
 	Value string
+	// Source describes where the value was read from
+	Source string
 }
 
-// LoadFromEnvFile loads secrets from a .env file
-func LoadFromEnvFile(path string, secretNames []string) ([]Secret, error) {
-	file, err := os.Open(path)
+// Load reads the values of the secrets in swarm.yaml with their
+// providers. Secrets without a provider are read from the encrypted
+// secrets file, then the env file, then environment variables.
+func Load(src Sources, entries []config.SecretConfig) ([]Secret, error) {
+	return NewResolver(src).Load(entries)
+}
+
+// Create creates a secret version for value unless one already holds it.
+// Versions are named <stack>_<name>_<hash>, so changing a secret never has
+// to remove one a running service uses. Each version is labelled with its
+// stack and a salted fingerprint of its value, used to find unchanged
+// secrets. It
+// returns the versioned name and whether a new version was created.
+func (m *Manager) Create(name, value string) (string, bool, error) {
+	_, existing, err := m.findVersion(name, value)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open .env file: %w", err)
+		return "", false, err
+	}
+	if existing != "" {
+		return existing, false, nil
 	}
-	defer file.Close()
-
-	envMap := make(map[string]string)
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
 
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			// Remove quotes if present
-			value = strings.Trim(value, "\"'")
-			envMap[key] = value
-		}
+	salt, err := newSalt()
+	if err != nil {
+		return "", false, err
+	}
+	fp := fingerprint(salt, value)
+	versionedName := m.store.HashedName(name, fp)
+
+	// The value goes through stdin so it never shows up in the command line
+	createCmd := fmt.Sprintf("docker secret create --label %s --label %s --label %s %s -",
+		shellquote.Join(stackLabel+"="+m.stackName), shellquote.Join(saltLabel+"="+salt),
+		shellquote.Join(fingerprintLabel+"="+fp), shellquote.Join(versionedName))
+	result, err := m.exec.RunWithStdin(createCmd, strings.NewReader(value))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create secret: %w", err)
 	}
 
-	var secrets []Secret
-	for _, name := range secretNames {
-		value, exists := envMap[name]
-		if !exists {
-			// Try environment variable
-			value = os.Getenv(name)
-		}
-		if value != "" {
-			secrets = append(secrets, Secret{Name: name, Value: value})
-		}
+	if result.ExitCode != 0 {
+		return "", false, fmt.Errorf("secret creation failed: %s", result.Stderr)
 	}
 
-	return secrets, nil
+	return versionedName, true, nil
 }
 
-// LoadFromEnv loads secrets from environment variables
-func LoadFromEnv(secretNames []string) []Secret {
-	var secrets []Secret
-	for _, name := range secretNames {
-		value := os.Getenv(name)
-		if value != "" {
-			secrets = append(secrets, Secret{Name: name, Value: value})
-		}
-	}
-	return secrets
+// Prune removes the versions of a secret other than current and the
+// previous one, kept for rollback, that no service uses anymore, and
+// returns the removed versions
+func (m *Manager) Prune(name, current string) ([]string, error) {
+	return m.store.Prune(name, current)
 }
 
-// Create creates a new secret in Docker Swarm
-func (m *Manager) Create(name, value string) error {
-	secretName := fmt.Sprintf("%s_%s", m.stackName, strings.ToLower(name))
-
-	// Check if secret exists
-	checkCmd := fmt.Sprintf("docker secret ls --filter name=%s --format '{{.Name}}'", secretName)
-	result, err := m.exec.Run(checkCmd)
-	if err != nil {
-		return err
-	}
+// UseVersions points the top-level compose secrets at the given versioned
+// Docker secrets. A secret NAME is matched by the definition keys
+// <stack>_<name>, <name> or NAME; definitions using file: are left alone.
+// Services keep referencing the definition key, so they roll over to the
+// new version on deploy.
+func (m *Manager) UseVersions(composeContent []byte, versions map[string]string) ([]byte, error) {
+	return useVersions(composeContent, m.stackName, versions, func(versioned string) *compose.Resource {
+		return &compose.Resource{Name: versioned, External: true}
+	})
+}
 
-	// Remove existing secret if it exists
-	if strings.TrimSpace(result.Stdout) != "" {
-		rmCmd := fmt.Sprintf("docker secret rm %s", secretName)
-		m.exec.Run(rmCmd)
+// useVersions replaces the top-level compose secret definitions matching
+// each secret with the resource returned for its version
+func useVersions(composeContent []byte, stackName string, versions map[string]string, resource func(versioned string) *compose.Resource) ([]byte, error) {
+	if len(versions) == 0 {
+		return composeContent, nil
 	}
 
-	// Create secret using echo and pipe
-	createCmd := fmt.Sprintf("echo -n '%s' | docker secret create %s -", value, secretName)
-	result, err = m.exec.Run(createCmd)
+	project, err := compose.Parse(composeContent)
 	if err != nil {
-		return fmt.Errorf("failed to create secret: %w", err)
+		return nil, err
 	}
 
-	if result.ExitCode != 0 {
-		return fmt.Errorf("secret creation failed: %s", result.Stderr)
+	changed := false
+	for name, versioned := range versions {
+		lower := strings.ToLower(name)
+		for _, key := range []string{fmt.Sprintf("%s_%s", stackName, lower), lower, name} {
+			existing, ok := project.Secrets[key]
+			if !ok || (existing != nil && existing.File != "") {
+				continue
+			}
+			project.Secrets[key] = resource(versioned)
+			changed = true
+			break
+		}
 	}
 
-	return nil
+	if !changed {
+		return composeContent, nil
+	}
+	return project.Marshal()
 }
 
 // List lists all secrets for the stack
 func (m *Manager) List() ([]string, error) {
-	cmd := fmt.Sprintf("docker secret ls --filter name=%s_ --format '{{.Name}}'", m.stackName)
+	return m.list(m.Known)
+}
+
+// list lists the secrets labelled with the stack, plus the unlabelled
+// ones named <stack>_<name> or <stack>_<name>_<hash> for one of known,
+// created before versions were labelled. The name prefix alone would also
+// match stacks such as <stack>_staging.
+func (m *Manager) list(known []string) ([]string, error) {
+	cmd := fmt.Sprintf("docker secret ls --filter name=%s --format '{{.Name}} {{.Label %q}}'", shellquote.Join(m.stackName+"_"), stackLabel)
 	result, err := m.exec.Run(cmd)
 	if err != nil {
 		return nil, err
 	}
-
-	if result.Stdout == "" {
-		return []string{}, nil
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("failed to list secrets: %s", result.Stderr)
 	}
 
-	secrets := strings.Split(strings.TrimSpace(result.Stdout), "\n")
+	secrets := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(result.Stdout), "\n") {
+		secret, stack, _ := strings.Cut(line, " ")
+		if secret == "" {
+			continue
+		}
+		if stack == m.stackName || (stack == "" && m.isKnown(secret, known)) {
+			secrets = append(secrets, secret)
+		}
+	}
 	return secrets, nil
 }
 
-// Delete deletes a secret
-func (m *Manager) Delete(name string) error {
-	secretName := fmt.Sprintf("%s_%s", m.stackName, strings.ToLower(name))
-	cmd := fmt.Sprintf("docker secret rm %s", secretName)
+// isKnown reports whether secret is named after one of known, versioned
+// or not
+func (m *Manager) isKnown(secret string, known []string) bool {
+	for _, name := range known {
+		if secret == m.stackName+"_"+strings.ToLower(name) || m.store.IsVersion(name, secret) {
+			return true
+		}
+	}
+	return false
+}
 
-	result, err := m.exec.Run(cmd)
+// Delete deletes every version of a secret, including an unversioned
+// <stack>_<name> secret created before versioning
+func (m *Manager) Delete(name string) error {
+	secrets, err := m.list([]string{name})
 	if err != nil {
-		return fmt.Errorf("failed to delete secret: %w", err)
+		return err
 	}
 
-	if result.ExitCode != 0 {
-		return fmt.Errorf("secret deletion failed: %s", result.Stderr)
+	var versions []string
+	for _, secret := range secrets {
+		if base, ok := m.store.BaseName(secret); ok && base == strings.ToLower(name) {
+			versions = append(versions, secret)
+		}
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("secret %s not found", name)
 	}
 
-	return nil
+	return m.Remove(versions)
 }
@@ -0,0 +1,295 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/marcelsud/swarmctl/internal/config"
+	"github.com/marcelsud/swarmctl/internal/dotenv"
+	"github.com/marcelsud/swarmctl/internal/vault"
+)
+
+// SecretProvider reads secret values from a source
+type SecretProvider interface {
+	// Value returns the value of a secret and whether it was found
+	Value(secret config.SecretConfig) (string, bool, error)
+
+	// Source describes where the value of a secret is read from
+	Source(secret config.SecretConfig) string
+}
+
+// DotenvProvider reads values from a .env file. A missing file holds no
+// values, unless it is Required.
+type DotenvProvider struct {
+	Path string
+	// Required makes a missing file an error, for files set explicitly
+	Required bool
+
+	env map[string]string
+}
+
+// Value returns the value of the secret's key in the .env file
+func (p *DotenvProvider) Value(secret config.SecretConfig) (string, bool, error) {
+	if p.env == nil {
+		_, err := os.Stat(p.Path)
+		if os.IsNotExist(err) && p.Required {
+			return "", false, fmt.Errorf("env file not found: %s", p.Path)
+		}
+		p.env = map[string]string{}
+		if err == nil {
+			env, err := dotenv.Read(p.Path)
+			if err != nil {
+				return "", false, err
+			}
+			p.env = env
+		}
+	}
+
+	value, ok := p.env[secret.LookupKey()]
+	return value, ok && value != "", nil
+}
+
+// Source returns the .env file path
+func (p *DotenvProvider) Source(secret config.SecretConfig) string {
+	return p.Path
+}
+
+// EncryptedProvider reads values from an encrypted secrets file. A missing
+// file holds no values; the key is only loaded when the file exists.
+type EncryptedProvider struct {
+	Path string
+	Key  func() (*vault.Key, error)
+
+	env map[string]string
+}
+
+// Value returns the value of the secret's key in the encrypted file
+func (p *EncryptedProvider) Value(secret config.SecretConfig) (string, bool, error) {
+	if p.env == nil {
+		p.env = map[string]string{}
+		if _, err := os.Stat(p.Path); err == nil {
+			key, err := p.Key()
+			if err != nil {
+				return "", false, err
+			}
+			env, err := vault.Read(p.Path, key)
+			if err != nil {
+				return "", false, err
+			}
+			p.env = env
+		}
+	}
+
+	value, ok := p.env[secret.LookupKey()]
+	return value, ok && value != "", nil
+}
+
+// Source returns the encrypted file path
+func (p *EncryptedProvider) Source(secret config.SecretConfig) string {
+	return p.Path
+}
+
+// EnvProvider reads values from environment variables
+type EnvProvider struct {
+	// Lookup defaults to os.LookupEnv
+	Lookup func(key string) (string, bool)
+}
+
+// Value returns the value of the secret's key in the environment
+func (p *EnvProvider) Value(secret config.SecretConfig) (string, bool, error) {
+	lookup := p.Lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	value, ok := lookup(secret.LookupKey())
+	return value, ok && value != "", nil
+}
+
+// Source describes the environment variable
+func (p *EnvProvider) Source(secret config.SecretConfig) string {
+	return "environment variable " + secret.LookupKey()
+}
+
+// FileProvider reads values from files, byte for byte
+type FileProvider struct {
+	// ReadFile defaults to os.ReadFile
+	ReadFile func(path string) ([]byte, error)
+}
+
+// Value returns the content of the secret's file
+func (p *FileProvider) Value(secret config.SecretConfig) (string, bool, error) {
+	readFile := p.ReadFile
+	if readFile == nil {
+		readFile = os.ReadFile
+	}
+	data, err := readFile(secret.File)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read secret %s: %w", secret.Name, err)
+	}
+	return string(data), len(data) > 0, nil
+}
+
+// Source returns the file path
+func (p *FileProvider) Source(secret config.SecretConfig) string {
+	return secret.File
+}
+
+// CommandProvider reads values from the output of local commands, such
+// as `pass show app/db`. A single trailing newline is removed.
+type CommandProvider struct {
+	// Run defaults to running the command with sh -c and returning stdout
+	Run func(command string) ([]byte, error)
+}
+
+// Value runs the secret's command and returns its output
+func (p *CommandProvider) Value(secret config.SecretConfig) (string, bool, error) {
+	run := p.Run
+	if run == nil {
+		run = runCommand
+	}
+	out, err := run(secret.Command)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read secret %s: %w", secret.Name, err)
+	}
+
+	value := strings.TrimSuffix(strings.TrimSuffix(string(out), "\n"), "\r")
+	return value, value != "", nil
+}
+
+// Source returns the command
+func (p *CommandProvider) Source(secret config.SecretConfig) string {
+	return "command `" + secret.Command + "`"
+}
+
+// runCommand runs a local command, passing stdin and stderr through so
+// password managers can prompt
+func runCommand(command string) ([]byte, error) {
+	var stdout bytes.Buffer
+	c := exec.Command("sh", "-c", command)
+	c.Stdin = os.Stdin
+	c.Stdout = &stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("command `%s` failed: %w", command, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// chainProvider returns the value from the first provider that has it
+type chainProvider []SecretProvider
+
+func (c chainProvider) Value(secret config.SecretConfig) (string, bool, error) {
+	value, _, ok, err := c.find(secret)
+	return value, ok, err
+}
+
+// find returns the value and the provider it was found in
+func (c chainProvider) find(secret config.SecretConfig) (string, SecretProvider, bool, error) {
+	for _, p := range c {
+		value, ok, err := p.Value(secret)
+		if err != nil || ok {
+			return value, p, ok, err
+		}
+	}
+	return "", nil, false, nil
+}
+
+func (c chainProvider) Source(secret config.SecretConfig) string {
+	sources := make([]string, len(c))
+	for i, p := range c {
+		sources[i] = p.Source(secret)
+	}
+	return strings.Join(sources, " or ")
+}
+
+// Resolver reads secret values with the provider configured for each
+// secret
+type Resolver struct {
+	// Providers maps provider names to providers
+	Providers map[string]SecretProvider
+	// Default is used for secrets without a provider
+	Default SecretProvider
+}
+
+// Sources holds the files secret values are read from
+type Sources struct {
+	// EnvFile is the .env file
+	EnvFile string
+	// RequireEnvFile makes a missing EnvFile an error instead of holding
+	// no values, for a file set with env_file or --env-file
+	RequireEnvFile bool
+	// EncryptedFile is the encrypted secrets file
+	EncryptedFile string
+	// Key loads the master key of EncryptedFile
+	Key func() (*vault.Key, error)
+}
+
+// NewResolver creates a Resolver with the built-in providers. Secrets
+// without a provider are read from the encrypted file, then the env file,
+// then the environment; missing files are skipped unless the env file is
+// required.
+func NewResolver(src Sources) *Resolver {
+	encryptedProvider := &EncryptedProvider{Path: src.EncryptedFile, Key: src.Key}
+	dotenvProvider := &DotenvProvider{Path: src.EnvFile, Required: src.RequireEnvFile}
+	envProvider := &EnvProvider{}
+	return &Resolver{
+		Providers: map[string]SecretProvider{
+			config.ProviderEncrypted: encryptedProvider,
+			config.ProviderDotenv:    dotenvProvider,
+			config.ProviderEnv:       envProvider,
+			config.ProviderFile:      &FileProvider{},
+			config.ProviderCommand:   &CommandProvider{},
+		},
+		Default: chainProvider{encryptedProvider, dotenvProvider, envProvider},
+	}
+}
+
+// provider returns the provider for a secret
+func (r *Resolver) provider(secret config.SecretConfig) (SecretProvider, error) {
+	if secret.Provider == "" {
+		return r.Default, nil
+	}
+	p, ok := r.Providers[secret.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider '%s' for secret %s", secret.Provider, secret.Name)
+	}
+	return p, nil
+}
+
+// Source describes where the value of a secret is read from
+func (r *Resolver) Source(secret config.SecretConfig) string {
+	p, err := r.provider(secret)
+	if err != nil {
+		return secret.Provider
+	}
+	return p.Source(secret)
+}
+
+// Load reads the values of secrets, skipping the ones without a value
+func (r *Resolver) Load(entries []config.SecretConfig) ([]Secret, error) {
+	var secrets []Secret
+	for _, entry := range entries {
+		p, err := r.provider(entry)
+		if err != nil {
+			return nil, err
+		}
+		// Report the provider of a chain the value actually came from
+		var value string
+		var ok bool
+		if chain, isChain := p.(chainProvider); isChain {
+			value, p, ok, err = chain.find(entry)
+		} else {
+			value, ok, err = p.Value(entry)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			secrets = append(secrets, Secret{Name: entry.Name, Value: value, Source: p.Source(entry)})
+		}
+	}
+	return secrets, nil
+}
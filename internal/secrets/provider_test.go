@@ -0,0 +1,248 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marcelsud/swarmctl/internal/config"
+	"github.com/marcelsud/swarmctl/internal/vault"
+)
+
+// staticProvider is a mock provider serving values from a map
+type staticProvider map[string]string
+
+func (p staticProvider) Value(secret config.SecretConfig) (string, bool, error) {
+	value, ok := p[secret.LookupKey()]
+	return value, ok, nil
+}
+
+func (p staticProvider) Source(secret config.SecretConfig) string {
+	return "static"
+}
+
+func TestDotenvProvider(t *testing.T) {
+	envPath := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envPath, []byte("DATABASE_URL=postgres://db\nPROD_DB=postgres://prod\nEMPTY=\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := &DotenvProvider{Path: envPath}
+
+	tests := []struct {
+		secret config.SecretConfig
+		want   string
+		wantOK bool
+	}{
+		{config.SecretConfig{Name: "DATABASE_URL"}, "postgres://db", true},
+		{config.SecretConfig{Name: "DATABASE_URL", Key: "PROD_DB"}, "postgres://prod", true},
+		{config.SecretConfig{Name: "EMPTY"}, "", false},
+		{config.SecretConfig{Name: "MISSING"}, "", false},
+	}
+	for _, tt := range tests {
+		value, ok, err := p.Value(tt.secret)
+		if err != nil || value != tt.want || ok != tt.wantOK {
+			t.Errorf("Value(%+v) = %q, %v, %v; want %q, %v", tt.secret, value, ok, err, tt.want, tt.wantOK)
+		}
+	}
+
+	missing := &DotenvProvider{Path: filepath.Join(t.TempDir(), ".env")}
+	if _, ok, err := missing.Value(config.SecretConfig{Name: "A"}); ok || err != nil {
+		t.Errorf("missing .env should hold no values, got %v, %v", ok, err)
+	}
+
+	required := &DotenvProvider{Path: filepath.Join(t.TempDir(), ".env.prod"), Required: true}
+	if _, _, err := required.Value(config.SecretConfig{Name: "A"}); err == nil || !strings.Contains(err.Error(), "env file not found") {
+		t.Errorf("missing required env file should be an error, got %v", err)
+	}
+}
+
+func TestResolverRequiredEnvFile(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://ci-runner")
+
+	r := NewResolver(Sources{EnvFile: filepath.Join(t.TempDir(), ".env.typo"), RequireEnvFile: true})
+	if _, err := r.Load([]config.SecretConfig{{Name: "DATABASE_URL"}}); err == nil || !strings.Contains(err.Error(), "env file not found") {
+		t.Errorf("expected a missing env file error instead of the environment value, got %v", err)
+	}
+}
+
+func TestEncryptedProvider(t *testing.T) {
+	key, err := vault.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	if err := vault.WriteFile(path, key, []byte("DATABASE_URL=postgres://db\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &EncryptedProvider{Path: path, Key: func() (*vault.Key, error) { return key, nil }}
+	value, ok, err := p.Value(config.SecretConfig{Name: "DATABASE_URL"})
+	if err != nil || !ok || value != "postgres://db" {
+		t.Errorf("expected decrypted value, got %q, %v, %v", value, ok, err)
+	}
+	if _, ok, _ := p.Value(config.SecretConfig{Name: "MISSING"}); ok {
+		t.Error("expected MISSING to have no value")
+	}
+
+	// The key is not needed when the file doesn't exist
+	missing := &EncryptedProvider{
+		Path: filepath.Join(t.TempDir(), "secrets.enc"),
+		Key:  func() (*vault.Key, error) { return nil, errors.New("no key") },
+	}
+	if _, ok, err := missing.Value(config.SecretConfig{Name: "A"}); ok || err != nil {
+		t.Errorf("missing file should hold no values, got %v, %v", ok, err)
+	}
+
+	other, _ := vault.GenerateKey()
+	wrongKey := &EncryptedProvider{Path: path, Key: func() (*vault.Key, error) { return other, nil }}
+	if _, _, err := wrongKey.Value(config.SecretConfig{Name: "DATABASE_URL"}); err == nil {
+		t.Error("expected error with the wrong key")
+	}
+}
+
+func TestNewResolverPrefersEncryptedFile(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := vault.GenerateKey()
+	encPath := filepath.Join(dir, "secrets.enc")
+	envPath := filepath.Join(dir, ".env")
+	if err := vault.WriteFile(encPath, key, []byte("A=encrypted\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(envPath, []byte("A=plain\nB=plain\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewResolver(Sources{
+		EnvFile:       envPath,
+		EncryptedFile: encPath,
+		Key:           func() (*vault.Key, error) { return key, nil },
+	})
+	loaded, err := r.Load([]config.SecretConfig{{Name: "A"}, {Name: "B"}})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("expected 2 secrets, got %d", len(loaded))
+	}
+	if loaded[0].Value != "encrypted" || loaded[0].Source != encPath {
+		t.Errorf("expected A from the encrypted file, got %+v", loaded[0])
+	}
+	if loaded[1].Value != "plain" || loaded[1].Source != envPath {
+		t.Errorf("expected B from the env file, got %+v", loaded[1])
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	p := &EnvProvider{Lookup: func(key string) (string, bool) {
+		if key == "APP_DB" {
+			return "postgres://db", true
+		}
+		return "", false
+	}}
+
+	if value, ok, _ := p.Value(config.SecretConfig{Name: "DATABASE_URL", Key: "APP_DB"}); !ok || value != "postgres://db" {
+		t.Errorf("expected value from key, got %q, %v", value, ok)
+	}
+	if _, ok, _ := p.Value(config.SecretConfig{Name: "DATABASE_URL"}); ok {
+		t.Error("unset variable should not be found")
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	p := &FileProvider{ReadFile: func(path string) ([]byte, error) {
+		if path == "/keys/tls.key" {
+			return []byte("-----BEGIN KEY-----\nabc\n-----END KEY-----\n"), nil
+		}
+		return nil, os.ErrNotExist
+	}}
+
+	value, ok, err := p.Value(config.SecretConfig{Name: "TLS_KEY", Provider: "file", File: "/keys/tls.key"})
+	if err != nil || !ok || value != "-----BEGIN KEY-----\nabc\n-----END KEY-----\n" {
+		t.Errorf("expected file content byte for byte, got %q, %v, %v", value, ok, err)
+	}
+
+	if _, _, err := p.Value(config.SecretConfig{Name: "OTHER", Provider: "file", File: "/missing"}); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestCommandProvider(t *testing.T) {
+	var ran []string
+	p := &CommandProvider{Run: func(command string) ([]byte, error) {
+		ran = append(ran, command)
+		switch command {
+		case "pass show app/db":
+			return []byte("postgres://db\n"), nil
+		case "pass show app/pem":
+			return []byte("line1\nline2\n\n"), nil
+		}
+		return nil, errors.New("exit status 1")
+	}}
+
+	value, ok, err := p.Value(config.SecretConfig{Name: "DATABASE_URL", Provider: "command", Command: "pass show app/db"})
+	if err != nil || !ok || value != "postgres://db" {
+		t.Errorf("expected trailing newline removed, got %q, %v, %v", value, ok, err)
+	}
+
+	value, _, _ = p.Value(config.SecretConfig{Name: "PEM", Provider: "command", Command: "pass show app/pem"})
+	if value != "line1\nline2\n" {
+		t.Errorf("only one trailing newline should be removed, got %q", value)
+	}
+
+	_, _, err = p.Value(config.SecretConfig{Name: "BROKEN", Provider: "command", Command: "false"})
+	if err == nil || !strings.Contains(err.Error(), "BROKEN") {
+		t.Errorf("expected error naming the secret, got %v", err)
+	}
+
+	if len(ran) != 3 {
+		t.Errorf("expected 3 commands, got %v", ran)
+	}
+}
+
+func TestCommandProviderRunsShell(t *testing.T) {
+	p := &CommandProvider{}
+	value, ok, err := p.Value(config.SecretConfig{Name: "A", Provider: "command", Command: "printf 'it%ss value\\n' \"'\""})
+	if err != nil || !ok || value != "it's value" {
+		t.Errorf("expected command output, got %q, %v, %v", value, ok, err)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	r := &Resolver{
+		Providers: map[string]SecretProvider{
+			"command": staticProvider{"DB": "from-command"},
+			"env":     staticProvider{"API_KEY": "from-env"},
+		},
+		Default: staticProvider{"PLAIN": "from-default"},
+	}
+
+	loaded, err := r.Load([]config.SecretConfig{
+		{Name: "DB", Provider: "command"},
+		{Name: "API_KEY", Provider: "env"},
+		{Name: "PLAIN"},
+		{Name: "MISSING"},
+	})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, s := range loaded {
+		got[s.Name] = s.Value
+	}
+	want := map[string]string{"DB": "from-command", "API_KEY": "from-env", "PLAIN": "from-default"}
+	if len(got) != len(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %q, want %q", name, got[name], value)
+		}
+	}
+
+	if _, err := r.Load([]config.SecretConfig{{Name: "X", Provider: "vault"}}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
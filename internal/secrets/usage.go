@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// Usage maps Docker secret names to the services using them
+type Usage map[string][]string
+
+// Services returns the services using any of the given Docker secrets,
+// sorted and without duplicates
+func (u Usage) Services(secrets ...string) []string {
+	seen := make(map[string]bool)
+	var services []string
+	for _, secret := range secrets {
+		for _, service := range u[secret] {
+			if !seen[service] {
+				seen[service] = true
+				services = append(services, service)
+			}
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Usage inspects the TaskTemplate.ContainerSpec.Secrets of every service
+// on the swarm, including services of other stacks
+func (m *Manager) Usage() (Usage, error) {
+	result, err := m.exec.Run("docker service ls --format '{{.Name}}'")
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("failed to list services: %s", result.Stderr)
+	}
+
+	services := strings.Fields(result.Stdout)
+	usage := make(Usage)
+	if len(services) == 0 {
+		return usage, nil
+	}
+
+	cmd := "docker service inspect --format '{{.Spec.Name}}{{range .Spec.TaskTemplate.ContainerSpec.Secrets}} {{.SecretName}}{{end}}' " + shellquote.Join(services...)
+	result, err = m.exec.Run(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("failed to inspect services: %s", result.Stderr)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(result.Stdout), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, secret := range fields[1:] {
+			usage[secret] = append(usage[secret], fields[0])
+		}
+	}
+	return usage, nil
+}
+
+// Versions lists the versions of a secret that exist on the swarm
+func (m *Manager) Versions(name string) ([]string, error) {
+	return m.store.Versions(name)
+}
+
+// Orphans returns the stack's Docker secrets that no service uses and that
+// don't belong to any of names, the secrets listed in swarm.yaml, or are
+// unversioned leftovers of them
+func (m *Manager) Orphans(names []string) ([]string, error) {
+	listed := make(map[string]bool, len(names))
+	for _, name := range names {
+		listed[strings.ToLower(name)] = true
+	}
+
+	remote, err := m.list(names)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := m.Usage()
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []string
+	for _, secret := range remote {
+		base, ok := m.store.BaseName(secret)
+		// Secrets are only created versioned, so nothing deploys an
+		// unversioned one anymore even if its name is still listed
+		unversioned := secret == m.stackName+"_"+base
+		if !ok || (listed[base] && !unversioned) || len(usage[secret]) > 0 {
+			continue
+		}
+		orphans = append(orphans, secret)
+	}
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+// Remove removes Docker secrets by their full name
+func (m *Manager) Remove(secrets []string) error {
+	for _, secret := range secrets {
+		result, err := m.exec.Run(fmt.Sprintf("docker secret rm %s", shellquote.Join(secret)))
+		if err != nil {
+			return fmt.Errorf("failed to delete secret: %w", err)
+		}
+
+		if result.ExitCode != 0 {
+			return fmt.Errorf("secret deletion failed: %s", result.Stderr)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marcelsud/swarmctl/internal/executor"
+)
+
+const inspectServicesFormat = "docker service inspect --format '{{.Spec.Name}}{{range .Spec.TaskTemplate.ContainerSpec.Secrets}} {{.SecretName}}{{end}}' "
+
+func TestUsage(t *testing.T) {
+	mock := NewSecretsMockExecutor()
+	mock.SetRunResult("docker service ls --format '{{.Name}}'", &executor.CommandResult{
+		Stdout: "myapp_web\nmyapp_worker\nother_api\n",
+	})
+	mock.SetRunResult(inspectServicesFormat+"myapp_web myapp_worker other_api", &executor.CommandResult{
+		Stdout: "myapp_web myapp_api_key_000000000001 myapp_db_000000000002\nmyapp_worker myapp_db_000000000002\nother_api\n",
+	})
+	m := NewManager(mock, "myapp")
+
+	usage, err := m.Usage()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := strings.Join(usage["myapp_db_000000000002"], ","); got != "myapp_web,myapp_worker" {
+		t.Errorf("unexpected services for db: %s", got)
+	}
+	if got := strings.Join(usage["myapp_api_key_000000000001"], ","); got != "myapp_web" {
+		t.Errorf("unexpected services for api_key: %s", got)
+	}
+	if got := usage.Services("myapp_api_key_000000000001", "myapp_db_000000000002"); strings.Join(got, ",") != "myapp_web,myapp_worker" {
+		t.Errorf("expected sorted services without duplicates, got %v", got)
+	}
+}
+
+func TestUsageNoServices(t *testing.T) {
+	mock := NewSecretsMockExecutor()
+	m := NewManager(mock, "myapp")
+
+	usage, err := m.Usage()
+	if err != nil || len(usage) != 0 {
+		t.Fatalf("expected empty usage, got %v, %v", usage, err)
+	}
+	if len(mock.runCommands) != 1 {
+		t.Errorf("expected no inspect without services, got %v", mock.runCommands)
+	}
+}
+
+func TestUsageFailure(t *testing.T) {
+	mock := NewSecretsMockExecutor()
+	mock.SetRunResult("docker service ls --format '{{.Name}}'", &executor.CommandResult{
+		ExitCode: 1,
+		Stderr:   "This node is not a swarm manager",
+	})
+	m := NewManager(mock, "myapp")
+
+	if _, err := m.Usage(); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestOrphans(t *testing.T) {
+	mock := NewSecretsMockExecutor()
+	mock.SetRunResult(listSecretsCmd, &executor.CommandResult{
+		Stdout: strings.Join([]string{
+			"myapp_api_key_000000000001 myapp",
+			"myapp_api_key ",
+			"myapp_api_key_000000000006 ",
+			"myapp_legacy_token_000000000002 myapp",
+			"myapp_legacy_token_000000000005 myapp",
+			"myapp_old_cert_000000000003 myapp",
+			"myapp_staging_token_000000000007 myapp_staging",
+		}, "\n") + "\n",
+	})
+	mock.SetRunResult("docker service ls --format '{{.Name}}'", &executor.CommandResult{
+		Stdout: "myapp_web\n",
+	})
+	mock.SetRunResult(inspectServicesFormat+"myapp_web", &executor.CommandResult{
+		Stdout: "myapp_web myapp_old_cert_000000000003\n",
+	})
+	m := NewManager(mock, "myapp")
+
+	orphans, err := m.Orphans([]string{"API_KEY"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The unversioned API_KEY predates versioning; its unlabelled version
+	// is kept like the labelled ones
+	want := "myapp_api_key,myapp_legacy_token_000000000002,myapp_legacy_token_000000000005"
+	if got := strings.Join(orphans, ","); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	mock := NewSecretsMockExecutor()
+	mock.SetRunResult("docker secret rm myapp_db_000000000002", &executor.CommandResult{
+		ExitCode: 1,
+		Stderr:   "secret is in use by service myapp_web",
+	})
+	m := NewManager(mock, "myapp")
+
+	if err := m.Remove([]string{"myapp_api_key_000000000001"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Remove([]string{"myapp_db_000000000002"}); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("expected docker error, got %v", err)
+	}
+}
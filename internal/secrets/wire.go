@@ -0,0 +1,108 @@
+package secrets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/marcelsud/swarmctl/internal/compose"
+	"github.com/marcelsud/swarmctl/internal/config"
+)
+
+// Wire adds the compose secret definitions and service references for
+// secrets from swarm.yaml, so the compose file doesn't have to declare
+// them. Each secret in names without a definition (<stack>_<name>,
+// <name> or NAME) gets an external <stack>_<name> one, which
+// UseVersions then points at the current version. Services listed in
+// swarm.yaml's services section get a reference to each of their
+// secrets, mounted at /run/secrets/<NAME>. Secrets not in names are
+// skipped and returned, sorted.
+func Wire(composeContent []byte, stackName string, names []string, services map[string]config.ServiceConfig) ([]byte, []string, error) {
+	project, err := compose.Parse(composeContent)
+	if err != nil {
+		return nil, nil, err
+	}
+	if project.Secrets == nil {
+		project.Secrets = make(map[string]*compose.Resource)
+	}
+
+	// Definition key of each secret, by lowercased name
+	changed := false
+	keys := make(map[string]string, len(names))
+	for _, name := range names {
+		key, ok := definitionKey(project, stackName, name)
+		if !ok {
+			project.Secrets[key] = &compose.Resource{External: true}
+			changed = true
+		}
+		keys[strings.ToLower(name)] = key
+	}
+
+	serviceNames := make([]string, 0, len(services))
+	for name := range services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+
+	skipped := make(map[string]bool)
+	for _, serviceName := range serviceNames {
+		svc, ok := project.Services[serviceName]
+		if !ok {
+			return nil, nil, fmt.Errorf("service %s from swarm.yaml not found in compose file", serviceName)
+		}
+		if svc == nil {
+			svc = &compose.Service{}
+			project.Services[serviceName] = svc
+		}
+
+		for _, name := range services[serviceName].Secrets {
+			key, ok := keys[strings.ToLower(name)]
+			if !ok {
+				skipped[name] = true
+				continue
+			}
+			if !referencesSecret(svc, key) {
+				svc.Secrets = append(svc.Secrets, compose.FileReference{Source: key, Target: name})
+				changed = true
+			}
+		}
+	}
+
+	out := composeContent
+	if changed {
+		if out, err = project.Marshal(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	missing := make([]string, 0, len(skipped))
+	for name := range skipped {
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+	return out, missing, nil
+}
+
+// definitionKey returns the key of the compose definition of a secret,
+// and false with the key to add when there is none
+func definitionKey(project *compose.Project, stackName, name string) (string, bool) {
+	lower := strings.ToLower(name)
+	candidates := []string{fmt.Sprintf("%s_%s", stackName, lower), lower, name}
+	for _, key := range candidates {
+		if _, ok := project.Secrets[key]; ok {
+			return key, true
+		}
+	}
+	return candidates[0], false
+}
+
+// referencesSecret reports whether a service already uses a secret
+// definition
+func referencesSecret(svc *compose.Service, key string) bool {
+	for _, ref := range svc.Secrets {
+		if ref.Source == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marcelsud/swarmctl/internal/compose"
+	"github.com/marcelsud/swarmctl/internal/config"
+)
+
+const wireCompose = `services:
+  web:
+    image: nginx
+  worker:
+    image: worker
+    secrets:
+      - myapp_database_url
+secrets:
+  api_key:
+    external: true
+`
+
+func TestWire(t *testing.T) {
+	out, missing, err := Wire([]byte(wireCompose), "myapp",
+		[]string{"DATABASE_URL", "API_KEY"},
+		map[string]config.ServiceConfig{
+			"web":    {Secrets: []string{"DATABASE_URL", "API_KEY", "SMTP_PASSWORD"}},
+			"worker": {Secrets: []string{"DATABASE_URL"}},
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(missing, ",") != "SMTP_PASSWORD" {
+		t.Errorf("expected SMTP_PASSWORD to be skipped, got %v", missing)
+	}
+
+	project, err := compose.Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if def := project.Secrets["myapp_database_url"]; def == nil || !def.External {
+		t.Errorf("expected external myapp_database_url definition, got %+v", def)
+	}
+	if _, ok := project.Secrets["myapp_api_key"]; ok {
+		t.Error("existing api_key definition should be reused")
+	}
+
+	web := project.Services["web"].Secrets
+	if len(web) != 2 || web[0].Source != "myapp_database_url" || web[0].Target != "DATABASE_URL" || web[1].Source != "api_key" || web[1].Target != "API_KEY" {
+		t.Errorf("unexpected web secrets %+v", web)
+	}
+	if worker := project.Services["worker"].Secrets; len(worker) != 1 {
+		t.Errorf("existing references should not be duplicated, got %+v", worker)
+	}
+}
+
+func TestWireUnknownService(t *testing.T) {
+	_, _, err := Wire([]byte(wireCompose), "myapp", []string{"DATABASE_URL"},
+		map[string]config.ServiceConfig{"api": {Secrets: []string{"DATABASE_URL"}}})
+	if err == nil || !strings.Contains(err.Error(), "service api") {
+		t.Errorf("expected unknown service error, got %v", err)
+	}
+}
+
+func TestWireUnchanged(t *testing.T) {
+	content := []byte(wireCompose)
+	out, _, err := Wire(content, "myapp", []string{"API_KEY"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != string(content) {
+		t.Errorf("content should be returned unchanged, got:\n%s", out)
+	}
+}
+
+func TestWireThenUseVersions(t *testing.T) {
+	out, _, err := Wire([]byte("services:\n  web:\n    image: nginx\n"), "myapp", []string{"DATABASE_URL"},
+		map[string]config.ServiceConfig{"web": {Secrets: []string{"DATABASE_URL"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := NewManager(NewSecretsMockExecutor(), "myapp")
+	out, err = m.UseVersions(out, map[string]string{"DATABASE_URL": "myapp_database_url_0123456789ab"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := string(out)
+	for _, want := range []string{"name: myapp_database_url_0123456789ab", "source: myapp_database_url", "target: DATABASE_URL"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package ssh
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -20,6 +24,18 @@ type Client struct {
 	User    string
 	KeyPath string
 
+	// ProxyJump lists the hosts the connection is made through, in order,
+	// as with ssh -J. Connect connects them and Close closes them.
+	ProxyJump []*Client
+
+	// KnownHostsFiles are the files host keys are checked against, with
+	// new keys added to the first; empty means ~/.ssh/known_hosts
+	KnownHostsFiles []string
+	// StrictHostKeyChecking is how keys of unknown hosts are handled, as
+	// in ssh_config: "yes" rejects them, "accept-new" and "no" add them
+	// without asking, and anything else asks first
+	StrictHostKeyChecking string
+
 	conn      *ssh.Client
 	config    *ssh.ClientConfig
 	agentConn net.Conn
@@ -35,41 +51,179 @@ func NewClient(host string, port int, user string, keyPath string) *Client {
 	}
 }
 
-// Connect establishes an SSH connection
+// connectTimeout bounds connecting to a host, from the TCP dial or the
+// direct-tcpip channel through a jump host to the end of the handshake
+var connectTimeout = 30 * time.Second
+
+// Connect establishes an SSH connection, through the ProxyJump hosts if
+// any. Host keys are checked at every hop.
 func (c *Client) Connect() error {
-	authMethods, err := c.getAuthMethods()
-	if err != nil {
-		return fmt.Errorf("failed to get auth methods: %w", err)
+	ctx := context.Background()
+	if len(c.ProxyJump) == 0 {
+		return c.dial(ctx)
 	}
 
-	hostKeyCallback := c.getHostKeyCallback()
+	var via *Client
+	for i, hop := range c.ProxyJump {
+		var err error
+		if via == nil {
+			err = hop.dial(ctx)
+		} else {
+			err = hop.connectThrough(ctx, via)
+		}
+		if err != nil {
+			c.closeJumps(i)
+			return fmt.Errorf("failed to connect to jump host %s: %w", hop.Host, err)
+		}
+		via = hop
+	}
 
-	c.config = &ssh.ClientConfig{
-		User:            c.User,
-		Auth:            authMethods,
-		HostKeyCallback: hostKeyCallback,
-		Timeout:         30 * time.Second,
+	if err := c.connectThrough(ctx, via); err != nil {
+		c.closeJumps(len(c.ProxyJump))
+		return err
 	}
+	return nil
+}
 
-	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
-	conn, err := ssh.Dial("tcp", addr, c.config)
+// dial connects directly to the client's host
+func (c *Client) dial(ctx context.Context) error {
+	if err := c.configure(); err != nil {
+		return err
+	}
+
+	addr := c.addr()
+	dialer := net.Dialer{Timeout: connectTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err == nil {
+		err = c.handshake(ctx, netConn, addr)
+	}
 	if err != nil {
+		c.Close()
 		return fmt.Errorf("failed to connect to %s: %w", addr, err)
 	}
+	return nil
+}
+
+// connectThrough connects to the client's host over a direct-tcpip
+// channel through via's connection. Keys and host key checks are local,
+// so via's host needs neither an ssh client nor agent forwarding.
+func (c *Client) connectThrough(ctx context.Context, via *Client) error {
+	if err := c.configure(); err != nil {
+		return err
+	}
+
+	addr := c.addr()
+	dialCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+	netConn, err := via.conn.DialContext(dialCtx, "tcp", addr)
+	cancel()
+	if err != nil {
+		c.Close()
+		return fmt.Errorf("failed to reach %s through %s: %w", addr, via.Host, err)
+	}
 
-	c.conn = conn
+	if err := c.handshake(ctx, netConn, addr); err != nil {
+		c.Close()
+		return fmt.Errorf("failed to connect to %s through %s: %w", addr, via.Host, err)
+	}
 	return nil
 }
 
-// Close closes the SSH connection
+// handshake runs the SSH handshake over netConn, giving up when the host
+// doesn't answer within connectTimeout or ctx is done. Channels through a
+// jump host don't support deadlines, so netConn is closed instead. Time
+// spent checking the host key, which may prompt, doesn't count.
+func (c *Client) handshake(ctx context.Context, netConn net.Conn, addr string) error {
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(connectTimeout, func() {
+		timedOut.Store(true)
+		netConn.Close()
+	})
+	stop := context.AfterFunc(ctx, func() { netConn.Close() })
+
+	config := *c.config
+	config.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		timer.Stop()
+		defer timer.Reset(connectTimeout)
+		return c.config.HostKeyCallback(hostname, remote, key)
+	}
+
+	conn, chans, reqs, err := ssh.NewClientConn(netConn, addr, &config)
+	if err == nil && (!timer.Stop() || !stop()) {
+		conn.Close()
+		err = errors.New("connection closed")
+	}
+	timer.Stop()
+	stop()
+
+	switch {
+	case err == nil:
+		c.conn = ssh.NewClient(conn, chans, reqs)
+		return nil
+	case timedOut.Load():
+		err = fmt.Errorf("no SSH handshake within %s", connectTimeout)
+	case ctx.Err() != nil:
+		err = ctx.Err()
+	}
+	netConn.Close()
+	return err
+}
+
+// Jump connects to a host reachable from this client's host, such as a
+// Swarm worker on an internal network, and returns a client for it. ctx
+// stops the connection attempt but not the returned client.
+func (c *Client) Jump(ctx context.Context, host string, port int, user string) (*Client, error) {
+	if c.conn == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	target := NewClient(host, port, user, c.KeyPath)
+	target.KnownHostsFiles = c.KnownHostsFiles
+	target.StrictHostKeyChecking = c.StrictHostKeyChecking
+	if err := target.connectThrough(ctx, c); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// configure sets up authentication and host key checks for the client
+func (c *Client) configure() error {
+	authMethods, err := c.getAuthMethods()
+	if err != nil {
+		return fmt.Errorf("failed to get auth methods: %w", err)
+	}
+
+	c.config = &ssh.ClientConfig{
+		User:            c.User,
+		Auth:            authMethods,
+		HostKeyCallback: c.getHostKeyCallback(),
+	}
+	return nil
+}
+
+// addr returns the host:port address of the client
+func (c *Client) addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// Close closes the SSH connection, then the jump hosts it was made
+// through
 func (c *Client) Close() error {
 	if c.agentConn != nil {
 		c.agentConn.Close()
 	}
+	var err error
 	if c.conn != nil {
-		return c.conn.Close()
+		err = c.conn.Close()
+	}
+	c.closeJumps(len(c.ProxyJump))
+	return err
+}
+
+// closeJumps closes the first n jump hosts, last first
+func (c *Client) closeJumps(n int) {
+	for i := n - 1; i >= 0; i-- {
+		c.ProxyJump[i].Close()
 	}
-	return nil
 }
 
 // HasAgentForwarding returns true if SSH agent is available for forwarding
@@ -165,12 +319,67 @@ func (c *Client) getHostKeyCallback() ssh.HostKeyCallback {
 		return ssh.InsecureIgnoreHostKey()
 	}
 
-	hostKeyCallback, err := knownhosts.New(filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts"))
+	files := c.KnownHostsFiles
+	if len(files) == 0 {
+		files = []string{filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts")}
+	}
+	var existing []string
+	for _, path := range files {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	hostKeyCallback, err := knownhosts.New(existing...)
 	if err != nil {
 		return c.confirmHostKeyCallback()
 	}
 
-	return hostKeyCallback
+	// Hosts missing from known_hosts are rejected unless
+	// StrictHostKeyChecking allows adding them; without any known_hosts
+	// file there is nothing to check against, so the user is asked. A
+	// changed key is always rejected.
+	mode := strings.ToLower(c.StrictHostKeyChecking)
+	if mode == "" && len(existing) == 0 {
+		mode = "ask"
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := hostKeyCallback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+			return err
+		}
+
+		switch mode {
+		case "accept-new", "no", "off":
+		case "ask":
+			if err := c.confirmHostKeyCallback()(hostname, remote, key); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("host key for %s is not in %s; add it with ssh-keyscan or set StrictHostKeyChecking accept-new", hostname, strings.Join(files, ", "))
+		}
+
+		if err := appendKnownHost(files[0], hostname, key); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to add host key to %s: %v\n", files[0], err)
+		}
+		return nil
+	}
+}
+
+// appendKnownHost adds a host key to a known_hosts file, creating it if
+// needed
+func appendKnownHost(path, hostname string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key))
+	return err
 }
 
 func (c *Client) confirmHostKeyCallback() ssh.HostKeyCallback {
@@ -1,8 +1,18 @@
 package ssh
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 func TestNewClient(t *testing.T) {
@@ -161,21 +171,6 @@ func TestClient_RunStreamWithoutConnection(t *testing.T) {
 	}
 }
 
-func TestClient_RunInteractiveViaHostWithoutConnection(t *testing.T) {
-	client := NewClient("test.example.com", 22, "testuser", "/path/to/key")
-
-	// RunInteractiveViaHost should fail without connection
-	err := client.RunInteractiveViaHost("target_example_com", "targetuser", "ls")
-	if err == nil {
-		t.Error("RunInteractiveViaHost should fail without connection")
-	}
-
-	expectedError := "not connected"
-	if err.Error() != expectedError {
-		t.Errorf("Expected error %q, got %q", expectedError, err.Error())
-	}
-}
-
 func TestClient_CopyFileWithoutConnection(t *testing.T) {
 	client := NewClient("test_example_com", 22, "testuser", "/path/to/key")
 
@@ -239,3 +234,362 @@ func containsString(s, substr string) bool {
 			s[len(s)-len(substr):] == substr ||
 			containsString(s[1:len(s)-1], substr))))
 }
+
+func TestClient_Jump(t *testing.T) {
+	worker := newTestServer(t, sleepHandler)
+	manager := newTestServer(t, sleepHandler)
+	keyPath := testHome(t, worker)
+
+	client := connectTest(t, manager)
+	client.Host = "manager"
+	client.KeyPath = keyPath
+
+	host, port := hostPort(t, worker.Addr)
+	node, err := client.Jump(context.Background(), host, port, "deploy")
+	if err != nil {
+		t.Fatalf("Jump() error = %v", err)
+	}
+	defer node.Close()
+
+	if dialed := manager.Dialed(); len(dialed) != 1 || dialed[0] != worker.Addr {
+		t.Errorf("manager dialed %v, want [%s]", dialed, worker.Addr)
+	}
+
+	result, err := node.Run("docker ps")
+	if err != nil {
+		t.Fatalf("Run() on node error = %v", err)
+	}
+	if result.Stdout != "docker ps\n" {
+		t.Errorf("Run() on node stdout = %q, want the worker's output", result.Stdout)
+	}
+
+	// Closing the node leaves the manager connection open
+	node.Close()
+	if _, err := client.Run("hello"); err != nil {
+		t.Errorf("Run() on manager after closing node error = %v", err)
+	}
+}
+
+func TestClient_Jump_HostKeyMismatch(t *testing.T) {
+	worker := newTestServer(t, sleepHandler)
+	other := newTestServer(t, sleepHandler)
+	manager := newTestServer(t, sleepHandler)
+	keyPath := testHome(t)
+
+	// known_hosts lists another key for the worker's address
+	line := knownhosts.Line([]string{knownhosts.Normalize(worker.Addr)}, other.HostKey)
+	if err := os.WriteFile(filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts"), []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := connectTest(t, manager)
+	client.KeyPath = keyPath
+
+	host, port := hostPort(t, worker.Addr)
+	if _, err := client.Jump(context.Background(), host, port, "deploy"); err == nil || !strings.Contains(err.Error(), "key mismatch") {
+		t.Errorf("Jump() error = %v, want a host key mismatch", err)
+	}
+}
+
+func TestClient_Jump_Unreachable(t *testing.T) {
+	manager := newTestServer(t, sleepHandler)
+	keyPath := testHome(t)
+
+	client := connectTest(t, manager)
+	client.Host = "manager"
+	client.KeyPath = keyPath
+
+	// Nothing listens on the closed listener's port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port := hostPort(t, l.Addr().String())
+	l.Close()
+
+	if _, err := client.Jump(context.Background(), host, port, "deploy"); err == nil || !strings.Contains(err.Error(), "through manager") {
+		t.Errorf("Jump() error = %v, want the address unreachable through the manager", err)
+	}
+}
+
+func TestClient_Jump_NoAnswer(t *testing.T) {
+	manager := newTestServer(t, sleepHandler)
+	keyPath := testHome(t)
+	setConnectTimeout(t, 200*time.Millisecond)
+
+	client := connectTest(t, manager)
+	client.Host = "manager"
+	client.KeyPath = keyPath
+
+	host, port := hostPort(t, newSilentServer(t))
+	start := time.Now()
+	if _, err := client.Jump(context.Background(), host, port, "deploy"); err == nil || !strings.Contains(err.Error(), "no SSH handshake within 200ms") {
+		t.Errorf("Jump() error = %v, want the handshake timed out", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Jump() took %s, want it bounded by the connect timeout", elapsed)
+	}
+
+	// The manager connection is still usable
+	if _, err := client.Run("hello"); err != nil {
+		t.Errorf("Run() on manager after the failed jump error = %v", err)
+	}
+}
+
+func TestClient_Jump_Canceled(t *testing.T) {
+	manager := newTestServer(t, sleepHandler)
+	keyPath := testHome(t)
+
+	client := connectTest(t, manager)
+	client.Host = "manager"
+	client.KeyPath = keyPath
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	host, port := hostPort(t, newSilentServer(t))
+	start := time.Now()
+	if _, err := client.Jump(ctx, host, port, "deploy"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Jump() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Jump() took %s, want it stopped by ctx", elapsed)
+	}
+}
+
+func TestClient_JumpWithoutConnection(t *testing.T) {
+	client := NewClient("test.example.com", 22, "testuser", "/path/to/key")
+
+	if _, err := client.Jump(context.Background(), "10.0.0.2", 22, "deploy"); err == nil || err.Error() != "not connected" {
+		t.Errorf("Jump() error = %v, want not connected", err)
+	}
+}
+
+// nameHandler answers every command with the server's name
+func nameHandler(name string) execHandler {
+	return func(cmd string, ch ssh.Channel, signals <-chan string) uint32 {
+		io.WriteString(ch, name+"\n")
+		return 0
+	}
+}
+
+// testClient returns an unconnected client for a test server
+func testClient(t *testing.T, s *testServer, keyPath string) *Client {
+	t.Helper()
+
+	host, port := hostPort(t, s.Addr)
+	return NewClient(host, port, "deploy", keyPath)
+}
+
+func TestClient_ConnectProxyJump(t *testing.T) {
+	first := newTestServer(t, nameHandler("first"))
+	second := newTestServer(t, nameHandler("second"))
+	manager := newTestServer(t, nameHandler("manager"))
+	keyPath := testHome(t, first, second, manager)
+
+	client := testClient(t, manager, keyPath)
+	client.ProxyJump = []*Client{testClient(t, first, keyPath), testClient(t, second, keyPath)}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+
+	result, err := client.Run("hostname")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Stdout != "manager\n" {
+		t.Errorf("Run() stdout = %q, want the manager's output", result.Stdout)
+	}
+
+	if dialed := first.Dialed(); len(dialed) != 1 || dialed[0] != second.Addr {
+		t.Errorf("first jump host dialed %v, want [%s]", dialed, second.Addr)
+	}
+	if dialed := second.Dialed(); len(dialed) != 1 || dialed[0] != manager.Addr {
+		t.Errorf("second jump host dialed %v, want [%s]", dialed, manager.Addr)
+	}
+	if dialed := manager.Dialed(); len(dialed) != 0 {
+		t.Errorf("manager dialed %v, want nothing", dialed)
+	}
+
+	// Workers are reached from the manager, past the jump hosts
+	worker := newTestServer(t, nameHandler("worker"))
+	known, err := os.OpenFile(filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts"), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	known.WriteString(knownhosts.Line([]string{knownhosts.Normalize(worker.Addr)}, worker.HostKey) + "\n")
+	known.Close()
+
+	host, port := hostPort(t, worker.Addr)
+	node, err := client.Jump(context.Background(), host, port, "deploy")
+	if err != nil {
+		t.Fatalf("Jump() error = %v", err)
+	}
+	defer node.Close()
+	if dialed := manager.Dialed(); len(dialed) != 1 || dialed[0] != worker.Addr {
+		t.Errorf("manager dialed %v, want [%s]", dialed, worker.Addr)
+	}
+}
+
+func TestClient_ConnectProxyJump_HostKeyMismatch(t *testing.T) {
+	first := newTestServer(t, nameHandler("first"))
+	second := newTestServer(t, nameHandler("second"))
+	manager := newTestServer(t, nameHandler("manager"))
+	keyPath := testHome(t, first, manager)
+
+	// known_hosts lists the manager's key for the second jump host
+	known, err := os.OpenFile(filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts"), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	known.WriteString(knownhosts.Line([]string{knownhosts.Normalize(second.Addr)}, manager.HostKey) + "\n")
+	known.Close()
+
+	client := testClient(t, manager, keyPath)
+	client.ProxyJump = []*Client{testClient(t, first, keyPath), testClient(t, second, keyPath)}
+	err = client.Connect()
+	if err == nil {
+		client.Close()
+		t.Fatal("Connect() error = nil, want a host key mismatch")
+	}
+	if !strings.Contains(err.Error(), "jump host") || !strings.Contains(err.Error(), "key mismatch") {
+		t.Errorf("Connect() error = %v, want a host key mismatch at the jump host", err)
+	}
+	if len(manager.Dialed()) != 0 || len(second.Dialed()) != 0 {
+		t.Error("Connect() went past the jump host with the mismatched key")
+	}
+}
+
+func TestClient_ConnectProxyJump_Unreachable(t *testing.T) {
+	bastion := newTestServer(t, nameHandler("bastion"))
+	keyPath := testHome(t, bastion)
+
+	// Nothing listens on the closed listener's port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	host, port := hostPort(t, addr)
+	client := NewClient(host, port, "deploy", keyPath)
+	client.ProxyJump = []*Client{testClient(t, bastion, keyPath)}
+	if err := client.Connect(); err == nil || !strings.Contains(err.Error(), "failed to reach "+addr) {
+		t.Errorf("Connect() error = %v, want %s unreachable through the jump host", err, addr)
+	}
+}
+
+func TestClient_ConnectProxyJump_NoAnswer(t *testing.T) {
+	bastion := newTestServer(t, nameHandler("bastion"))
+	manager := newTestServer(t, nameHandler("manager"))
+	keyPath := testHome(t, bastion, manager)
+	setConnectTimeout(t, 200*time.Millisecond)
+
+	silent, silentPort := hostPort(t, newSilentServer(t))
+	tests := []struct {
+		name string
+		hops func() []*Client
+	}{
+		{"first jump host", func() []*Client {
+			return []*Client{NewClient(silent, silentPort, "deploy", keyPath), testClient(t, bastion, keyPath)}
+		}},
+		{"second jump host", func() []*Client {
+			return []*Client{testClient(t, bastion, keyPath), NewClient(silent, silentPort, "deploy", keyPath)}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := testClient(t, manager, keyPath)
+			client.ProxyJump = tt.hops()
+
+			start := time.Now()
+			err := client.Connect()
+			if err == nil {
+				client.Close()
+				t.Fatal("Connect() error = nil, want the stalled jump host to fail")
+			}
+			if !strings.Contains(err.Error(), "jump host "+silent) || !strings.Contains(err.Error(), "no SSH handshake") {
+				t.Errorf("Connect() error = %v, want a handshake timeout at the stalled jump host", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Connect() took %s, want it bounded by the connect timeout", elapsed)
+			}
+		})
+	}
+
+	// A stalled target behind a working jump host fails the same way
+	client := NewClient(silent, silentPort, "deploy", keyPath)
+	client.ProxyJump = []*Client{testClient(t, bastion, keyPath)}
+	if err := client.Connect(); err == nil || !strings.Contains(err.Error(), "no SSH handshake") {
+		t.Errorf("Connect() error = %v, want a handshake timeout at the target", err)
+	}
+}
+
+func TestClient_StrictHostKeyChecking(t *testing.T) {
+	server := newTestServer(t, nameHandler("manager"))
+	keyPath := testHome(t)
+
+	for _, mode := range []string{"", "yes"} {
+		client := testClient(t, server, keyPath)
+		client.StrictHostKeyChecking = mode
+		if err := client.Connect(); err == nil || !strings.Contains(err.Error(), "is not in") {
+			client.Close()
+			t.Fatalf("StrictHostKeyChecking %q: Connect() error = %v, want the unknown host rejected", mode, err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 {
+		t.Errorf("known_hosts = %q, want the rejected key not added", data)
+	}
+}
+
+func TestClient_StrictHostKeyCheckingAcceptNew(t *testing.T) {
+	server := newTestServer(t, nameHandler("manager"))
+	keyPath := testHome(t)
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts_prod")
+
+	client := testClient(t, server, keyPath)
+	client.KnownHostsFiles = []string{knownHosts}
+	client.StrictHostKeyChecking = "accept-new"
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	client.Close()
+
+	data, err := os.ReadFile(knownHosts)
+	if err != nil {
+		t.Fatalf("known hosts file not written: %v", err)
+	}
+	if want := knownhosts.Line([]string{knownhosts.Normalize(server.Addr)}, server.HostKey); strings.TrimSpace(string(data)) != want {
+		t.Errorf("known hosts file = %q, want %q", data, want)
+	}
+
+	// The remembered key is now required
+	client = testClient(t, server, keyPath)
+	client.KnownHostsFiles = []string{knownHosts}
+	client.StrictHostKeyChecking = "yes"
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() with remembered key error = %v", err)
+	}
+	client.Close()
+
+	other := newTestServer(t, nameHandler("other"))
+	line := knownhosts.Line([]string{knownhosts.Normalize(other.Addr)}, server.HostKey)
+	if err := os.WriteFile(knownHosts, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client = testClient(t, other, keyPath)
+	client.KnownHostsFiles = []string{knownHosts}
+	client.StrictHostKeyChecking = "accept-new"
+	if err := client.Connect(); err == nil || !strings.Contains(err.Error(), "key mismatch") {
+		client.Close()
+		t.Errorf("Connect() error = %v, want a changed key rejected", err)
+	}
+}
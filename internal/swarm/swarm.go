@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/kballard/go-shellquote"
 	"github.com/marcelsud/swarmctl/internal/executor"
 )
 
@@ -97,8 +98,9 @@ func (m *Manager) RegistryLogin(url, username, password string) error {
 		return nil // Skip if no credentials
 	}
 
-	cmd := fmt.Sprintf("echo '%s' | docker login %s -u %s --password-stdin", password, url, username)
-	result, err := m.exec.Run(cmd)
+	// The password goes through stdin so it never shows up in the command line
+	cmd := fmt.Sprintf("docker login %s -u %s --password-stdin", shellquote.Join(url), shellquote.Join(username))
+	result, err := m.exec.RunWithStdin(cmd, strings.NewReader(password))
 	if err != nil {
 		return fmt.Errorf("failed to login to registry: %w", err)
 	}
@@ -1,6 +1,7 @@
 package swarm
 
 import (
+	"context"
 	"errors"
 	"io"
 	"testing"
@@ -13,6 +14,7 @@ type SwarmMockExecutor struct {
 	runCommands []string
 	runResults  map[string]*executor.CommandResult
 	runErrors   map[string]error
+	stdinInputs map[string]string
 }
 
 func NewSwarmMockExecutor() *SwarmMockExecutor {
@@ -20,6 +22,7 @@ func NewSwarmMockExecutor() *SwarmMockExecutor {
 		runCommands: make([]string, 0),
 		runResults:  make(map[string]*executor.CommandResult),
 		runErrors:   make(map[string]error),
+		stdinInputs: make(map[string]string),
 	}
 }
 
@@ -52,6 +55,27 @@ func (m *SwarmMockExecutor) RunStream(cmd string, stdout, stderr io.Writer) erro
 	return nil
 }
 
+func (m *SwarmMockExecutor) RunContext(ctx context.Context, cmd string) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
+func (m *SwarmMockExecutor) RunInteractiveContext(ctx context.Context, cmd string) error {
+	return m.RunInteractive(cmd)
+}
+
+func (m *SwarmMockExecutor) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	return m.RunStream(cmd, stdout, stderr)
+}
+
+func (m *SwarmMockExecutor) RunWithStdin(cmd string, stdin io.Reader) (*executor.CommandResult, error) {
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, err
+	}
+	m.stdinInputs[cmd] = string(data)
+	return m.Run(cmd)
+}
+
 func (m *SwarmMockExecutor) WriteFile(path string, content []byte) error {
 	return nil
 }
@@ -268,7 +292,7 @@ func TestManager_RegistryLogin_WithCredentials(t *testing.T) {
 	mockExec := NewSwarmMockExecutor()
 	manager := NewManager(mockExec, "test-stack")
 
-	cmd := "echo 'password123' | docker login registry.example.com -u user1 --password-stdin"
+	cmd := "docker login registry.example.com -u user1 --password-stdin"
 	mockExec.SetRunResult(cmd, &executor.CommandResult{
 		Stdout:   "Login Succeeded",
 		ExitCode: 0,
@@ -290,6 +314,28 @@ func TestManager_RegistryLogin_WithCredentials(t *testing.T) {
 	if !found {
 		t.Errorf("Expected command %q not found", cmd)
 	}
+	if mockExec.stdinInputs[cmd] != "password123" {
+		t.Errorf("password should be passed on stdin, got %q", mockExec.stdinInputs[cmd])
+	}
+}
+
+func TestManager_RegistryLogin_PasswordNotInCommand(t *testing.T) {
+	mockExec := NewSwarmMockExecutor()
+	manager := NewManager(mockExec, "test-stack")
+
+	password := "it's$(reboot)"
+	if err := manager.RegistryLogin("registry.example.com", "user1", password); err != nil {
+		t.Fatalf("RegistryLogin() error = %v", err)
+	}
+
+	for _, runCmd := range mockExec.GetRunCommands() {
+		if containsString(runCmd, password) {
+			t.Errorf("password leaked into command: %q", runCmd)
+		}
+	}
+	if mockExec.stdinInputs["docker login registry.example.com -u user1 --password-stdin"] != password {
+		t.Errorf("password should be passed on stdin unchanged, got %v", mockExec.stdinInputs)
+	}
 }
 
 func TestManager_RegistryLogin_EmptyCredentials(t *testing.T) {
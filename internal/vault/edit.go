@@ -0,0 +1,44 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Edit opens content in $EDITOR (vi if unset) and returns the edited
+// content. The plaintext only touches disk in a private temporary
+// directory that is removed afterwards.
+func Edit(content []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "swarmctl-secrets-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "secrets.env")
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Run through sh so EDITOR may hold arguments, e.g. "code --wait"
+	c := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("editor failed: %w", err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edited file: %w", err)
+	}
+	return edited, nil
+}
@@ -0,0 +1,200 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/marcelsud/swarmctl/internal/dotenv"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// KeySize is the size in bytes of a master key
+const KeySize = 32
+
+// KeyEnv is the environment variable holding the hex-encoded master key
+const KeyEnv = "SWARMCTL_MASTER_KEY"
+
+// Dir is the directory, next to swarm.yaml, holding encrypted secrets
+// files and key files
+const Dir = ".swarmctl"
+
+// header prefixes encrypted files, identifying the format version
+const header = "swarmctl:secretbox:v1:"
+
+const nonceSize = 24
+
+// Key is a master key used to encrypt secrets files
+type Key [KeySize]byte
+
+// GenerateKey returns a new random master key
+func GenerateKey() (*Key, error) {
+	key := new(Key)
+	if _, err := rand.Read(key[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate master key: %w", err)
+	}
+	return key, nil
+}
+
+// ParseKey parses a hex-encoded master key
+func ParseKey(s string) (*Key, error) {
+	b, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != KeySize {
+		return nil, fmt.Errorf("master key must be %d hex characters", KeySize*2)
+	}
+	key := new(Key)
+	copy(key[:], b)
+	return key, nil
+}
+
+// String returns the hex encoding of the key
+func (k *Key) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+// FilePath returns the encrypted secrets file for a destination:
+// .swarmctl/secrets.<destination>.enc, or .swarmctl/secrets.enc
+func FilePath(dir, destination string) string {
+	if destination == "" {
+		return filepath.Join(dir, Dir, "secrets.enc")
+	}
+	return filepath.Join(dir, Dir, "secrets."+destination+".enc")
+}
+
+// KeyPath returns the key file for a destination: .swarmctl/<destination>.key
+// if it exists, otherwise .swarmctl/master.key
+func KeyPath(dir, destination string) string {
+	if destination != "" {
+		path := filepath.Join(dir, Dir, destination+".key")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, Dir, "master.key")
+}
+
+// LoadKey returns the master key from SWARMCTL_MASTER_KEY, or from the key
+// file for the destination
+func LoadKey(dir, destination string) (*Key, error) {
+	if value := os.Getenv(KeyEnv); value != "" {
+		key, err := ParseKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", KeyEnv, err)
+		}
+		return key, nil
+	}
+
+	path := KeyPath(dir, destination)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("master key not found: set %s or create %s", KeyEnv, path)
+		}
+		return nil, fmt.Errorf("failed to read master key: %w", err)
+	}
+	key, err := ParseKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key in %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// WriteKey writes a key file readable only by the owner, and makes sure
+// key files in its directory are ignored by git
+func WriteKey(path string, key *Key) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	gitignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(gitignore); os.IsNotExist(err) {
+		if err := os.WriteFile(gitignore, []byte("*.key\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", gitignore, err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(key.String()+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write master key: %w", err)
+	}
+	return nil
+}
+
+// Encrypt seals plaintext with the key using nacl/secretbox
+func Encrypt(key *Key, plaintext []byte) ([]byte, error) {
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := secretbox.Seal(nonce[:], plaintext, &nonce, (*[KeySize]byte)(key))
+	return []byte(header + base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+// Decrypt opens data sealed by Encrypt
+func Decrypt(key *Key, data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte(header)) {
+		return nil, errors.New("not a swarmctl encrypted secrets file")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(string(data[len(header):]))
+	if err != nil || len(sealed) < nonceSize {
+		return nil, errors.New("encrypted secrets file is corrupted")
+	}
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], sealed[:nonceSize])
+	plaintext, ok := secretbox.Open(nil, sealed[nonceSize:], &nonce, (*[KeySize]byte)(key))
+	if !ok {
+		return nil, errors.New("failed to decrypt secrets file: wrong master key or corrupted file")
+	}
+	return plaintext, nil
+}
+
+// ReadFile decrypts the file at path
+func ReadFile(path string, key *Key) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encrypted secrets file: %w", err)
+	}
+	plaintext, err := Decrypt(key, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plaintext, nil
+}
+
+// WriteFile encrypts plaintext into the file at path
+func WriteFile(path string, key *Key, plaintext []byte) error {
+	data, err := Encrypt(key, plaintext)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write encrypted secrets file: %w", err)
+	}
+	return nil
+}
+
+// Read decrypts the file at path and parses it as a .env file
+func Read(path string, key *Key) (map[string]string, error) {
+	plaintext, err := ReadFile(path, key)
+	if err != nil {
+		return nil, err
+	}
+	env, err := dotenv.Parse(bytes.NewReader(plaintext))
+	if perr, ok := err.(*dotenv.ParseError); ok {
+		perr.File = path
+	}
+	return env, err
+}
@@ -0,0 +1,183 @@
+package vault
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("DATABASE_URL=postgres://db\nPEM=\"-----BEGIN-----\nabc\n-----END-----\"\n")
+
+	data, err := Encrypt(key, plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !strings.HasPrefix(string(data), header) {
+		t.Errorf("expected header, got %q", data)
+	}
+	if strings.Contains(string(data), "postgres") {
+		t.Error("ciphertext contains plaintext")
+	}
+
+	got, err := Decrypt(key, data)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if string(got) != string(plaintext) {
+		t.Errorf("expected %q, got %q", plaintext, got)
+	}
+
+	// A fresh nonce is used for each encryption
+	again, _ := Encrypt(key, plaintext)
+	if string(again) == string(data) {
+		t.Error("expected different ciphertexts for the same plaintext")
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	key, _ := GenerateKey()
+	other, _ := GenerateKey()
+	data, _ := Encrypt(key, []byte("A=1\n"))
+
+	sealed, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data[len(header):])))
+	sealed[len(sealed)-1] ^= 1
+	tampered := []byte(header + base64.StdEncoding.EncodeToString(sealed))
+
+	tests := []struct {
+		name string
+		key  *Key
+		data []byte
+	}{
+		{"wrong key", other, data},
+		{"no header", key, []byte("A=1\n")},
+		{"bad base64", key, []byte(header + "!!!")},
+		{"too short", key, []byte(header + "AAAA")},
+		{"tampered", key, tampered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decrypt(tt.key, tt.data); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key, _ := GenerateKey()
+	parsed, err := ParseKey(key.String() + "\n")
+	if err != nil {
+		t.Fatalf("ParseKey failed: %v", err)
+	}
+	if *parsed != *key {
+		t.Error("parsed key differs")
+	}
+
+	for _, s := range []string{"", "abcd", "zz" + key.String()[2:]} {
+		if _, err := ParseKey(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	if got := FilePath("/app", ""); got != "/app/.swarmctl/secrets.enc" {
+		t.Errorf("unexpected path %s", got)
+	}
+	if got := FilePath("/app", "staging"); got != "/app/.swarmctl/secrets.staging.enc" {
+		t.Errorf("unexpected path %s", got)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(KeyEnv, "")
+
+	if _, err := LoadKey(dir, "staging"); err == nil || !strings.Contains(err.Error(), KeyEnv) {
+		t.Errorf("expected missing key error mentioning %s, got %v", KeyEnv, err)
+	}
+
+	master, _ := GenerateKey()
+	if err := WriteKey(filepath.Join(dir, Dir, "master.key"), master); err != nil {
+		t.Fatal(err)
+	}
+	key, err := LoadKey(dir, "staging")
+	if err != nil || *key != *master {
+		t.Errorf("expected master key as fallback, got %v", err)
+	}
+
+	staging, _ := GenerateKey()
+	if err := WriteKey(filepath.Join(dir, Dir, "staging.key"), staging); err != nil {
+		t.Fatal(err)
+	}
+	key, err = LoadKey(dir, "staging")
+	if err != nil || *key != *staging {
+		t.Errorf("expected destination key, got %v", err)
+	}
+
+	fromEnv, _ := GenerateKey()
+	t.Setenv(KeyEnv, fromEnv.String())
+	key, err = LoadKey(dir, "staging")
+	if err != nil || *key != *fromEnv {
+		t.Errorf("expected key from %s, got %v", KeyEnv, err)
+	}
+
+	t.Setenv(KeyEnv, "not-hex")
+	if _, err := LoadKey(dir, "staging"); err == nil {
+		t.Error("expected error for invalid key in environment")
+	}
+}
+
+func TestWriteKey(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), Dir)
+	key, _ := GenerateKey()
+	path := filepath.Join(dir, "master.key")
+
+	if err := WriteKey(path, key); err != nil {
+		t.Fatalf("WriteKey failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+
+	gitignore, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil || !strings.Contains(string(gitignore), "*.key") {
+		t.Errorf("expected .gitignore ignoring key files, got %q, %v", gitignore, err)
+	}
+}
+
+func TestReadWriteFile(t *testing.T) {
+	key, _ := GenerateKey()
+	path := filepath.Join(t.TempDir(), Dir, "secrets.enc")
+
+	if err := WriteFile(path, key, []byte("# comment\nA=1\nB='two words'\n")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	env, err := Read(path, key)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if env["A"] != "1" || env["B"] != "two words" {
+		t.Errorf("unexpected values %v", env)
+	}
+
+	if err := WriteFile(path, key, []byte("A='unterminated\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path, key); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("expected parse error mentioning %s, got %v", path, err)
+	}
+}
@@ -0,0 +1,197 @@
+package versioned
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/marcelsud/swarmctl/internal/executor"
+)
+
+// HashLength is the number of hex characters of the content hash kept in
+// versioned names
+const HashLength = 12
+
+// Kind is the type of Docker object being versioned
+type Kind string
+
+const (
+	// Config versions Docker configs
+	Config Kind = "config"
+	// Secret versions Docker secrets
+	Secret Kind = "secret"
+)
+
+// Store names, lists and prunes content-versioned Docker configs or
+// secrets. Both are immutable and can't be removed while a service uses
+// them, so every content change gets a new <stack>_<name>_<hash> object
+// and old versions are pruned once nothing uses them.
+type Store struct {
+	exec      executor.Executor
+	stackName string
+	kind      Kind
+}
+
+// NewStore creates a new Store for objects of the given kind
+func NewStore(exec executor.Executor, stackName string, kind Kind) *Store {
+	return &Store{
+		exec:      exec,
+		stackName: stackName,
+		kind:      kind,
+	}
+}
+
+// Name returns the versioned name for a version of an object
+func (s *Store) Name(name string, content []byte) string {
+	sum := sha256.Sum256(content)
+	return s.HashedName(name, hex.EncodeToString(sum[:]))
+}
+
+// HashedName returns the versioned name for a version identified by a hex
+// hash, truncated to HashLength
+func (s *Store) HashedName(name, hash string) string {
+	return s.prefix(name) + hash[:HashLength]
+}
+
+// BaseName returns the lowercased name of the object a stack object is a
+// version of, e.g. myapp_api_key_3f2a9c1b7e4d -> api_key. Unversioned
+// names are returned without the stack prefix. It returns false for names
+// without the stack prefix; it can't tell whether a name with the prefix
+// belongs to another stack, such as myapp_staging for myapp.
+func (s *Store) BaseName(objectName string) (string, bool) {
+	base, ok := strings.CutPrefix(objectName, s.stackName+"_")
+	if !ok || base == "" {
+		return "", false
+	}
+	if i := len(base) - HashLength - 1; i > 0 && isVersion(base, base[:i+1]) {
+		base = base[:i]
+	}
+	return base, true
+}
+
+// IsVersion reports whether objectName is a version of the object name
+func (s *Store) IsVersion(name, objectName string) bool {
+	return isVersion(objectName, s.prefix(name))
+}
+
+// Versions lists the versions of an object that exist on the swarm
+func (s *Store) Versions(name string) ([]string, error) {
+	prefix := s.prefix(name)
+	cmd := fmt.Sprintf("docker %s ls --filter name=%s --format '{{.Name}}'", s.kind, shellquote.Join(prefix))
+	result, err := s.exec.Run(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("failed to list %ss: %s", s.kind, result.Stderr)
+	}
+
+	// The name filter matches prefixes, so drop objects that only share it
+	var versions []string
+	for _, line := range strings.Split(strings.TrimSpace(result.Stdout), "\n") {
+		if isVersion(line, prefix) {
+			versions = append(versions, line)
+		}
+	}
+	return versions, nil
+}
+
+// Exists reports whether a versioned name exists on the swarm
+func (s *Store) Exists(name, versioned string) (bool, error) {
+	versions, err := s.Versions(name)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range versions {
+		if v == versioned {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Prune removes the versions of an object other than current and the
+// newest of the others, which the previous service spec still refers to,
+// so docker service update --rollback can use it. Versions still used by
+// a service are kept too, as Docker refuses to remove them. It returns
+// the removed versions.
+func (s *Store) Prune(name, current string) ([]string, error) {
+	versions, err := s.Versions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var old []string
+	for _, v := range versions {
+		if v != current {
+			old = append(old, v)
+		}
+	}
+	if len(old) == 0 {
+		return nil, nil
+	}
+	previous, err := s.newest(old)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, v := range old {
+		if v == previous {
+			continue
+		}
+		result, err := s.exec.Run(fmt.Sprintf("docker %s rm %s", s.kind, shellquote.Join(v)))
+		if err != nil || result.ExitCode != 0 {
+			continue
+		}
+		removed = append(removed, v)
+	}
+	return removed, nil
+}
+
+// newest returns the most recently created of the given objects
+func (s *Store) newest(objects []string) (string, error) {
+	cmd := fmt.Sprintf("docker %s inspect --format '{{.CreatedAt.UnixNano}} {{.Spec.Name}}' %s", s.kind, shellquote.Join(objects...))
+	result, err := s.exec.Run(cmd)
+	if err != nil {
+		return "", err
+	}
+	if result.ExitCode != 0 {
+		return "", fmt.Errorf("failed to inspect %ss: %s", s.kind, result.Stderr)
+	}
+
+	var newest string
+	var newestAt int64
+	for _, line := range strings.Split(strings.TrimSpace(result.Stdout), "\n") {
+		at, name, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		created, err := strconv.ParseInt(at, 10, 64)
+		if err != nil {
+			continue
+		}
+		if newest == "" || created > newestAt {
+			newest, newestAt = name, created
+		}
+	}
+	if newest == "" {
+		return "", fmt.Errorf("failed to find the newest %s of %s", s.kind, strings.Join(objects, ", "))
+	}
+	return newest, nil
+}
+
+func (s *Store) prefix(name string) string {
+	return fmt.Sprintf("%s_%s_", s.stackName, strings.ToLower(name))
+}
+
+func isVersion(s, prefix string) bool {
+	if !strings.HasPrefix(s, prefix) || len(s) != len(prefix)+HashLength {
+		return false
+	}
+	_, err := hex.DecodeString(s[len(prefix):])
+	return err == nil
+}
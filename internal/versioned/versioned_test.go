@@ -0,0 +1,218 @@
+package versioned
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/marcelsud/swarmctl/internal/executor"
+)
+
+// VersionedMockExecutor for testing versioned objects
+type VersionedMockExecutor struct {
+	runCommands []string
+	runResults  map[string]*executor.CommandResult
+}
+
+func NewVersionedMockExecutor() *VersionedMockExecutor {
+	return &VersionedMockExecutor{
+		runCommands: make([]string, 0),
+		runResults:  make(map[string]*executor.CommandResult),
+	}
+}
+
+func (m *VersionedMockExecutor) Run(cmd string) (*executor.CommandResult, error) {
+	m.runCommands = append(m.runCommands, cmd)
+
+	if result, exists := m.runResults[cmd]; exists {
+		return result, nil
+	}
+
+	// Default successful result
+	return &executor.CommandResult{ExitCode: 0}, nil
+}
+
+func (m *VersionedMockExecutor) RunInteractive(cmd string) error {
+	m.runCommands = append(m.runCommands, cmd)
+	return nil
+}
+
+func (m *VersionedMockExecutor) RunStream(cmd string, stdout, stderr io.Writer) error {
+	m.runCommands = append(m.runCommands, cmd)
+	return nil
+}
+
+func (m *VersionedMockExecutor) RunContext(ctx context.Context, cmd string) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
+func (m *VersionedMockExecutor) RunInteractiveContext(ctx context.Context, cmd string) error {
+	return m.RunInteractive(cmd)
+}
+
+func (m *VersionedMockExecutor) RunStreamContext(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	return m.RunStream(cmd, stdout, stderr)
+}
+
+func (m *VersionedMockExecutor) RunWithStdin(cmd string, stdin io.Reader) (*executor.CommandResult, error) {
+	return m.Run(cmd)
+}
+
+func (m *VersionedMockExecutor) WriteFile(path string, content []byte) error {
+	return nil
+}
+
+func (m *VersionedMockExecutor) Close() error {
+	return nil
+}
+
+func (m *VersionedMockExecutor) IsLocal() bool {
+	return false
+}
+
+func (m *VersionedMockExecutor) SetVerbose(v bool) {
+	// Mock implementation - just store the value
+}
+
+func (m *VersionedMockExecutor) SetRunResult(cmd string, result *executor.CommandResult) {
+	m.runResults[cmd] = result
+}
+
+func TestStoreName(t *testing.T) {
+	s := NewStore(nil, "myapp", Secret)
+
+	name := s.Name("DATABASE_URL", []byte("postgres://db"))
+	if !strings.HasPrefix(name, "myapp_database_url_") {
+		t.Errorf("expected prefix myapp_database_url_, got %s", name)
+	}
+	if len(name) != len("myapp_database_url_")+HashLength {
+		t.Errorf("unexpected name length: %s", name)
+	}
+	if name != s.Name("DATABASE_URL", []byte("postgres://db")) {
+		t.Error("same content should give the same name")
+	}
+	if name == s.Name("DATABASE_URL", []byte("postgres://other")) {
+		t.Error("different content should give a different name")
+	}
+}
+
+func TestStoreVersions(t *testing.T) {
+	mock := NewVersionedMockExecutor()
+	mock.SetRunResult("docker secret ls --filter name=myapp_api_key_ --format '{{.Name}}'", &executor.CommandResult{
+		Stdout: "myapp_api_key_0123456789ab\nmyapp_api_key_v2_0123456789ab\nmyapp_api_key_notahexvalue\n",
+	})
+
+	s := NewStore(mock, "myapp", Secret)
+	versions, err := s.Versions("API_KEY")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(versions) != 1 || versions[0] != "myapp_api_key_0123456789ab" {
+		t.Errorf("expected only the real version, got %v", versions)
+	}
+
+	exists, err := s.Exists("API_KEY", "myapp_api_key_0123456789ab")
+	if err != nil || !exists {
+		t.Errorf("expected version to exist, got %v (err %v)", exists, err)
+	}
+}
+
+func TestStoreVersionsError(t *testing.T) {
+	mock := NewVersionedMockExecutor()
+	mock.SetRunResult("docker config ls --filter name=myapp_app_ --format '{{.Name}}'", &executor.CommandResult{
+		ExitCode: 1,
+		Stderr:   "this node is not a swarm manager",
+	})
+
+	s := NewStore(mock, "myapp", Config)
+	if _, err := s.Versions("app"); err == nil || !strings.Contains(err.Error(), "not a swarm manager") {
+		t.Errorf("expected list error, got %v", err)
+	}
+}
+
+func TestStorePrune(t *testing.T) {
+	mock := NewVersionedMockExecutor()
+	mock.SetRunResult("docker secret ls --filter name=myapp_api_key_ --format '{{.Name}}'", &executor.CommandResult{
+		Stdout: "myapp_api_key_000000000001\nmyapp_api_key_000000000002\nmyapp_api_key_000000000003\nmyapp_api_key_000000000004\n",
+	})
+	mock.SetRunResult("docker secret inspect --format '{{.CreatedAt.UnixNano}} {{.Spec.Name}}' myapp_api_key_000000000001 myapp_api_key_000000000002 myapp_api_key_000000000003", &executor.CommandResult{
+		Stdout: "1700000000000000000 myapp_api_key_000000000001\n1700000300000000000 myapp_api_key_000000000002\n1700000200000000000 myapp_api_key_000000000003\n",
+	})
+	mock.SetRunResult("docker secret rm myapp_api_key_000000000003", &executor.CommandResult{
+		ExitCode: 1,
+		Stderr:   "secret is in use by the following service: myapp_web",
+	})
+
+	s := NewStore(mock, "myapp", Secret)
+	removed, err := s.Prune("API_KEY", "myapp_api_key_000000000004")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "myapp_api_key_000000000001" {
+		t.Errorf("expected only the unused old version removed, got %v", removed)
+	}
+	for _, cmd := range mock.runCommands {
+		switch cmd {
+		case "docker secret rm myapp_api_key_000000000004":
+			t.Error("current version should not be removed")
+		case "docker secret rm myapp_api_key_000000000002":
+			t.Error("previous version should be kept for rollback")
+		}
+	}
+}
+
+func TestStorePrune_OnlyPrevious(t *testing.T) {
+	mock := NewVersionedMockExecutor()
+	mock.SetRunResult("docker secret ls --filter name=myapp_api_key_ --format '{{.Name}}'", &executor.CommandResult{
+		Stdout: "myapp_api_key_000000000001\nmyapp_api_key_000000000002\n",
+	})
+	mock.SetRunResult("docker secret inspect --format '{{.CreatedAt.UnixNano}} {{.Spec.Name}}' myapp_api_key_000000000001", &executor.CommandResult{
+		Stdout: "1700000000000000000 myapp_api_key_000000000001\n",
+	})
+
+	s := NewStore(mock, "myapp", Secret)
+	removed, err := s.Prune("API_KEY", "myapp_api_key_000000000002")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(removed) != 0 {
+		t.Errorf("expected the previous version kept, got %v removed", removed)
+	}
+}
+
+func TestStoreBaseName(t *testing.T) {
+	s := NewStore(nil, "myapp", Secret)
+
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"myapp_api_key_0123456789ab", "api_key", true},
+		{"myapp_api_key", "api_key", true},
+		{"myapp_api_key_notahexvalue", "api_key_notahexvalue", true},
+		{"other_api_key_0123456789ab", "", false},
+		{"myapp-production_api_key", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := s.BaseName(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("BaseName(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestStoreIsVersion(t *testing.T) {
+	s := NewStore(nil, "myapp", Secret)
+
+	if !s.IsVersion("API_KEY", "myapp_api_key_0123456789ab") {
+		t.Error("expected a version of API_KEY")
+	}
+	for _, name := range []string{"myapp_api_key", "myapp_api_key_notahexvalue", "myapp_api_key_old_0123456789ab", "other_api_key_0123456789ab"} {
+		if s.IsVersion("API_KEY", name) {
+			t.Errorf("%s should not be a version of API_KEY", name)
+		}
+	}
+}
@@ -6,8 +6,6 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/marcelsud/swarmctl/internal/accessories"
-	"github.com/marcelsud/swarmctl/internal/config"
-	"github.com/marcelsud/swarmctl/internal/executor"
 	"github.com/spf13/cobra"
 )
 
@@ -52,7 +50,7 @@ func runAccessoryList(cmd *cobra.Command, args []string) {
 	yellow := color.New(color.FgYellow).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
 
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
@@ -63,7 +61,7 @@ func runAccessoryList(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)
@@ -92,13 +90,13 @@ func runAccessoryStart(cmd *cobra.Command, args []string) {
 
 	target := args[0]
 
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
 	}
 
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)
@@ -126,13 +124,13 @@ func runAccessoryStop(cmd *cobra.Command, args []string) {
 
 	target := args[0]
 
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
 	}
 
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)
@@ -160,13 +158,13 @@ func runAccessoryRestart(cmd *cobra.Command, args []string) {
 
 	target := args[0]
 
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
 	}
 
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/marcelsud/swarmctl/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+	Long:  `Inspect the swarm.yaml configuration.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the resolved configuration",
+	Long: `Show the fully resolved configuration.
+
+With -d, the destination file (e.g. swarm.staging.yaml) is merged on top
+of swarm.yaml. Each value is annotated with the file it came from.
+
+ Examples:
+  swarmctl config show
+  swarmctl config show -d staging`,
+	Args: cobra.NoArgs,
+	Run:  runConfigShow,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration",
+	Long: `Validate swarm.yaml (and the destination file, with -d).
+
+Reports unknown keys with their line and column, missing required
+environment variables and invalid values.
+
+ Examples:
+  swarmctl config validate
+  swarmctl config validate -d production`,
+	Args: cobra.NoArgs,
+	Run:  runConfigValidate,
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for swarm.yaml",
+	Long: `Print a JSON Schema describing swarm.yaml.
+
+Point your editor's YAML language server at it to get autocompletion
+and validation while editing.
+
+ Examples:
+  swarmctl config schema > swarm.schema.json`,
+	Args: cobra.NoArgs,
+	Run:  runConfigSchema,
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) {
+	red := color.New(color.FgRed).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	doc, err := config.Resolve(configFile, destination)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	doc.Redact("registry.password")
+
+	out, err := doc.Annotated()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	files := make([]string, len(doc.Files))
+	for i, f := range doc.Files {
+		files[i] = filepath.Base(f)
+	}
+
+	fmt.Printf("%s Resolved from: %v\n\n", cyan("→"), files)
+	fmt.Print(string(out))
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) {
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s Configuration is valid\n", green("✓"))
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) {
+	red := color.New(color.FgRed).SprintFunc()
+
+	schema, err := config.Schema()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	fmt.Println(string(schema))
+}
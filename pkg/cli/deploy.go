@@ -3,13 +3,17 @@ package cli
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/marcelsud/swarmctl/internal/compose"
 	"github.com/marcelsud/swarmctl/internal/config"
+	"github.com/marcelsud/swarmctl/internal/configs"
 	"github.com/marcelsud/swarmctl/internal/deployment"
 	"github.com/marcelsud/swarmctl/internal/executor"
+	"github.com/marcelsud/swarmctl/internal/preflight"
 	"github.com/marcelsud/swarmctl/internal/secrets"
 	"github.com/marcelsud/swarmctl/internal/swarm"
 	"github.com/spf13/cobra"
@@ -18,6 +22,7 @@ import (
 var (
 	deployService         string
 	deploySkipAccessories bool
+	deploySkipRegistries  bool
 )
 
 var deployCmd = &cobra.Command{
@@ -27,6 +32,7 @@ var deployCmd = &cobra.Command{
 This command will:
 - Load and validate configuration
 - Connect via SSH (if configured)
+- Run preflight checks and abort if any fails
 - Push secrets if changed
 - Deploy the stack (swarm mode or compose mode)
 - Wait for services to become healthy
@@ -37,6 +43,7 @@ This command will:
 func init() {
 	deployCmd.Flags().StringVarP(&deployService, "service", "s", "", "deploy only this service")
 	deployCmd.Flags().BoolVar(&deploySkipAccessories, "skip-accessories", false, "skip accessory services")
+	deployCmd.Flags().BoolVar(&deploySkipRegistries, "skip-registry-check", false, "don't check registry credentials, for public images")
 }
 
 func runDeploy(cmd *cobra.Command, args []string) {
@@ -50,7 +57,7 @@ func runDeploy(cmd *cobra.Command, args []string) {
 
 	// Load config
 	fmt.Printf("%s Loading configuration...\n", cyan("→"))
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
@@ -72,15 +79,33 @@ func runDeploy(cmd *cobra.Command, args []string) {
 
 	// Load compose file
 	fmt.Printf("%s Loading compose file...\n", cyan("→"))
-	composeContent, err := config.LoadComposeFile(cfg.ComposeFile)
+	composeContent, err := config.LoadComposeFiles(cfg.ComposeFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
 	}
-	fmt.Printf("  %s %s\n", green("✓"), cfg.ComposeFile)
+	for _, file := range cfg.ComposeFile {
+		fmt.Printf("  %s %s\n", green("✓"), file)
+	}
+
+	// Interpolate compose variables locally; the remote host doesn't
+	// have our environment
+	lookup, err := cfg.ComposeLookup(envFile(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+	composeContent, unset, err := compose.Interpolate(composeContent, lookup)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+	for _, name := range unset {
+		fmt.Printf("  %s The %s variable is not set. Defaulting to a blank string.\n", yellow("!"), name)
+	}
 
 	// Create executor
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)
@@ -96,15 +121,36 @@ func runDeploy(cmd *cobra.Command, args []string) {
 		fmt.Printf("%s Connected to %s\n", green("✓"), cfg.SSH.Host)
 	}
 
+	// Load secret values up front; preflight needs to know which secrets
+	// the deploy will create. A provider that fails aborts the deploy
+	// rather than deploying services without their secrets.
+	var secretList []secrets.Secret
+	if len(cfg.Secrets) > 0 {
+		secretList, err = secrets.Load(secretSources(cfg, envFile(cfg)), cfg.Secrets)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s Failed to load secrets, nothing was deployed: %v\n", red("✗"), err)
+			os.Exit(1)
+		}
+		redactSecrets(exec, secretList)
+	}
+
+	// Check everything the deploy depends on before changing anything
+	if err := runPreflight(exec, cfg, composeContent, secretList); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
 	// Create deployment manager
 	mgr := deployment.New(cfg, exec)
 
-	// Push secrets if any are defined and we're in Swarm mode
+	// Push secrets if any are defined; in compose mode they are written to
+	// files on the host
+	secretVersions := make(map[string]string)
 	if len(cfg.Secrets) > 0 {
 		fmt.Printf("%s Checking secrets...\n", cyan("→"))
 
 		// Check current secrets on the remote
-		secretsMgr := secrets.NewManager(exec, cfg.Stack)
+		secretsMgr := secrets.New(cfg, exec)
 		existingSecrets, err := secretsMgr.List()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s Failed to check existing secrets: %v\n", yellow("!"), err)
@@ -113,19 +159,7 @@ func runDeploy(cmd *cobra.Command, args []string) {
 			fmt.Printf("  %d secrets exist on remote\n", len(existingSecrets))
 		}
 
-		// Load secrets from .env file or environment
-		var secretList []secrets.Secret
-		if _, err := os.Stat(".env"); err == nil {
-			secretList, err = secrets.LoadFromEnvFile(".env", cfg.Secrets)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "%s Failed to load secrets from .env: %v\n", yellow("!"), err)
-			} else {
-				fmt.Printf("  %d secrets loaded from .env\n", len(secretList))
-			}
-		} else {
-			secretList = secrets.LoadFromEnv(cfg.Secrets)
-			fmt.Printf("  %d secrets loaded from environment\n", len(secretList))
-		}
+		fmt.Printf("  %d of %d secrets loaded\n", len(secretList), len(cfg.Secrets))
 
 		// Push secrets if we found any
 		if len(secretList) > 0 {
@@ -133,14 +167,60 @@ func runDeploy(cmd *cobra.Command, args []string) {
 
 			for _, secret := range secretList {
 				fmt.Printf("  %s %s...", cyan("→"), secret.Name)
-				if err := secretsMgr.Create(secret.Name, secret.Value); err != nil {
+				versioned, created, err := secretsMgr.Create(secret.Name, secret.Value)
+				if err != nil {
 					fmt.Printf(" %s (%v)\n", red("✗"), err)
 					// Continue with deploy even if some secrets fail
-				} else {
-					fmt.Printf(" %s\n", green("✓"))
+					continue
+				}
+				secretVersions[secret.Name] = versioned
+
+				status := "unchanged"
+				if created {
+					status = "created"
 				}
+				fmt.Printf(" %s %s (%s)\n", green("✓"), versioned, status)
 			}
 		}
+
+		// Declare the pushed secrets and attach them to the services listed
+		// in swarm.yaml
+		pushed := make([]string, 0, len(secretVersions))
+		for name := range secretVersions {
+			pushed = append(pushed, name)
+		}
+		wired, missing, err := secrets.Wire(composeContent, cfg.Stack, pushed, cfg.Services)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+			os.Exit(1)
+		}
+		composeContent = wired
+		for _, name := range missing {
+			fmt.Printf("  %s %s has no value, not attached to services\n", yellow("!"), name)
+		}
+
+		// Point the compose file at the current versions so services roll over
+		composeContent, err = secretsMgr.UseVersions(composeContent, secretVersions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+			os.Exit(1)
+		}
+	}
+
+	// Create versioned configs; in compose mode config files are mounted directly
+	configVersions := make(map[string]string)
+	if len(cfg.Configs) > 0 {
+		fmt.Printf("%s Checking configs...\n", cyan("→"))
+
+		if cfg.Mode == config.ModeCompose {
+			composeContent, err = configs.UseFiles(composeContent, cfg.Configs)
+		} else {
+			composeContent, err = pushConfigs(exec, cfg, composeContent, configVersions)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+			os.Exit(1)
+		}
 	}
 
 	// Login to registry if configured (use swarm manager for registry login)
@@ -161,34 +241,56 @@ func runDeploy(cmd *cobra.Command, args []string) {
 		fmt.Printf("%s Deploying stack %s...\n", cyan("→"), bold(cfg.Stack))
 	}
 
-	// Remove accessories from compose if skip flag is set
+	// Accessories are removed from the compose content by the manager
 	if deploySkipAccessories {
-		fmt.Printf("%s Filtering out accessories...\n", cyan("→"))
-		
-		// For now, just show a warning and continue
-		// TODO: Implement proper YAML filtering
-		fmt.Printf("  %s Warning: Skip accessories not fully implemented - deploying all services\n", yellow("!"))
+		if len(cfg.Accessories) == 0 {
+			fmt.Printf("  %s No accessories defined in swarm.yaml, nothing to skip\n", yellow("!"))
+		} else {
+			fmt.Printf("  Skipping accessories: %s\n", strings.Join(cfg.Accessories, ", "))
+		}
 	}
 
 	// Filter compose content if specific service
 	if deployService != "" {
-		composeContent = filterServiceFromCompose(composeContent, deployService)
+		composeContent, err = filterServiceFromCompose(composeContent, deployService)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+			os.Exit(1)
+		}
+	}
+
+	// Paths inside the compose file are relative to the first compose file
+	baseDir, err := filepath.Abs(filepath.Dir(cfg.ComposeFile[0]))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
 	}
 
-	if err := mgr.Deploy(composeContent); err != nil {
+	opts := deployment.DeployOptions{
+		SkipAccessories: deploySkipAccessories,
+		BaseDir:         baseDir,
+	}
+	if err := mgr.DeployWithOptions(composeContent, opts); err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to deploy: %v\n", red("✗"), err)
 		os.Exit(1)
 	}
 	fmt.Printf("  %s Stack deployed\n", green("✓"))
 
-	// Wait for services to become healthy
-	fmt.Printf("%s Waiting for services to become healthy...\n", cyan("→"))
-	timeout := 2 * time.Minute
-	if err := mgr.WaitForHealthy(timeout); err != nil {
-		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
-		fmt.Printf("%s Deploy completed with health check timeout\n", yellow("!"))
+	// Wait for services to become healthy; nothing was deployed in a dry
+	// run, so there is nothing to wait for or prune
+	if dryRun {
+		fmt.Printf("%s Dry run: skipping health check and pruning\n", yellow("!"))
 	} else {
-		fmt.Printf("  %s All services are healthy\n", green("✓"))
+		fmt.Printf("%s Waiting for services to become healthy...\n", cyan("→"))
+		timeout := 2 * time.Minute
+		if err := mgr.WaitForHealthy(timeout); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+			fmt.Printf("%s Deploy completed with health check timeout\n", yellow("!"))
+		} else {
+			fmt.Printf("  %s All services are healthy\n", green("✓"))
+			pruneSecrets(exec, cfg, secretVersions)
+			pruneConfigs(exec, cfg, configVersions)
+		}
 	}
 
 	// Show status
@@ -207,6 +309,60 @@ func runDeploy(cmd *cobra.Command, args []string) {
 	fmt.Printf("\n%s Deploy completed in %s\n", green("✓"), elapsed.Round(time.Millisecond))
 }
 
+// runPreflight runs the preflight checks against the services that will
+// be deployed and prints them as a checklist. It returns an error if any
+// check failed.
+func runPreflight(exec executor.Executor, cfg *config.Config, composeContent []byte, secretList []secrets.Secret) error {
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	fmt.Printf("%s Running preflight checks...\n", cyan("→"))
+
+	project, err := compose.Parse(composeContent)
+	if err != nil {
+		return err
+	}
+	if deployService != "" {
+		if err := project.KeepServices(deployService); err != nil {
+			return err
+		}
+	}
+	if deploySkipAccessories {
+		project.RemoveServices(cfg.Accessories...)
+	}
+	content, err := project.Marshal()
+	if err != nil {
+		return err
+	}
+
+	checker := preflight.NewChecker(exec, cfg)
+	checker.SkipRegistries = deploySkipRegistries
+	for _, secret := range secretList {
+		checker.Secrets = append(checker.Secrets, secret.Name)
+	}
+	results, err := checker.Run(content)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		switch {
+		case !r.OK:
+			fmt.Printf("  %s %s: %s\n", red("✗"), r.Name, r.Detail)
+		case r.Detail != "":
+			fmt.Printf("  %s %s (%s)\n", green("✓"), r.Name, r.Detail)
+		default:
+			fmt.Printf("  %s %s\n", green("✓"), r.Name)
+		}
+	}
+
+	if preflight.Failed(results) {
+		return fmt.Errorf("preflight checks failed, nothing was deployed")
+	}
+	return nil
+}
+
 func truncateImage(image string) string {
 	if len(image) > 50 {
 		return image[:47] + "..."
@@ -215,92 +371,79 @@ func truncateImage(image string) string {
 }
 
 // filterServiceFromCompose removes all services except the specified one from compose content
-func filterServiceFromCompose(composeContent []byte, serviceName string) []byte {
-	// Parse the compose content to extract only the specified service
-	// This is a simple implementation - for production, use a proper YAML parser
+func filterServiceFromCompose(composeContent []byte, serviceName string) ([]byte, error) {
+	project, err := compose.Parse(composeContent)
+	if err != nil {
+		return nil, err
+	}
 
-	content := string(composeContent)
-	lines := strings.Split(content, "\n")
+	if err := project.KeepServices(serviceName); err != nil {
+		return nil, err
+	}
 
-	var result []string
-	inServices := false
-	inTargetService := false
-	serviceIndent := ""
+	fmt.Printf("  Filtered compose to deploy only service: %s\n", serviceName)
+	return project.Marshal()
+}
 
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
+// pushConfigs creates a versioned Docker config for each config in
+// swarm.yaml and points the compose file at them. The versions used are
+// recorded in versions.
+func pushConfigs(exec executor.Executor, cfg *config.Config, composeContent []byte, versions map[string]string) ([]byte, error) {
+	green := color.New(color.FgGreen).SprintFunc()
 
-		// Check if we're entering services section
-		if trimmed == "services:" {
-			inServices = true
-			result = append(result, line)
-			continue
+	items, err := configs.Load(cfg.Configs)
+	if err != nil {
+		return nil, err
+	}
+
+	configsMgr := configs.NewManager(exec, cfg.Stack)
+	for _, item := range items {
+		versioned, created, err := configsMgr.Create(item.Name, item.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create config %s: %w", item.Name, err)
 		}
+		versions[item.Name] = versioned
 
-		// If we're in services section
-		if inServices {
-			// Check if this is a service definition (ends with : and has proper indent)
-			if strings.HasSuffix(trimmed, ":") && !strings.HasPrefix(trimmed, "#") {
-				// Extract indent level
-				indent := ""
-				for _, ch := range line {
-					if ch == ' ' || ch == '\t' {
-						indent += string(ch)
-					} else {
-						break
-					}
-				}
+		status := "unchanged"
+		if created {
+			status = "created"
+		}
+		fmt.Printf("  %s %s → %s (%s)\n", green("✓"), item.Name, versioned, status)
+	}
 
-				// Check if this is our target service
-				serviceNameFromLine := strings.TrimSuffix(trimmed, ":")
-				if serviceNameFromLine == serviceName {
-					inTargetService = true
-					serviceIndent = indent
-					result = append(result, line)
-				} else {
-					inTargetService = false
-				}
-				continue
-			}
+	return configs.UseVersions(composeContent, versions)
+}
 
-			// If we're in the target service, include all lines with same or greater indent
-			if inTargetService {
-				currentIndent := ""
-				for _, ch := range line {
-					if ch == ' ' || ch == '\t' {
-						currentIndent += string(ch)
-					} else {
-						break
-					}
-				}
+// pruneConfigs removes config versions no longer used after a deploy
+func pruneConfigs(exec executor.Executor, cfg *config.Config, versions map[string]string) {
+	yellow := color.New(color.FgYellow).SprintFunc()
 
-				// Include line if it has same indent as service or greater (sub-items)
-				if len(currentIndent) >= len(serviceIndent) {
-					result = append(result, line)
-				} else if trimmed == "" {
-					// Empty lines are okay
-					result = append(result, line)
-				} else {
-					// We've left the service definition
-					inTargetService = false
-				}
-			}
-		} else {
-			// Not in services section, include everything
-			result = append(result, line)
+	configsMgr := configs.NewManager(exec, cfg.Stack)
+	for name, current := range versions {
+		removed, err := configsMgr.Prune(name, current)
+		if err != nil {
+			fmt.Printf("  %s Failed to prune old versions of %s: %v\n", yellow("!"), name, err)
+			continue
+		}
+		for _, v := range removed {
+			fmt.Printf("  Removed unused config %s\n", v)
 		}
 	}
+}
 
-	// Reconstruct the compose content
-	filteredContent := strings.Join(result, "\n")
+// pruneSecrets removes secret versions no longer used after a deploy
+func pruneSecrets(exec executor.Executor, cfg *config.Config, versions map[string]string) {
+	yellow := color.New(color.FgYellow).SprintFunc()
 
-	// Add basic services structure if we only have the target service
-	if !strings.Contains(filteredContent, "services:") {
-		filteredContent = "services:\n" + filteredContent
+	secretsMgr := secrets.New(cfg, exec)
+	for name, current := range versions {
+		removed, err := secretsMgr.Prune(name, current)
+		if err != nil {
+			fmt.Printf("  %s Failed to prune old versions of %s: %v\n", yellow("!"), name, err)
+			continue
+		}
+		for _, v := range removed {
+			fmt.Printf("  Removed unused secret %s\n", v)
+		}
 	}
-
-	fmt.Printf("  Filtered compose to deploy only service: %s\n", serviceName)
-	return []byte(filteredContent)
 }
-
-
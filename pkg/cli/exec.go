@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -8,7 +9,6 @@ import (
 	"github.com/fatih/color"
 	"github.com/marcelsud/swarmctl/internal/config"
 	"github.com/marcelsud/swarmctl/internal/deployment"
-	"github.com/marcelsud/swarmctl/internal/executor"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +19,8 @@ var execCmd = &cobra.Command{
 If no command is provided, opens an interactive shell.
 
 For Swarm mode, automatically detects if the container is running on a worker
-node and uses SSH hop through the manager to reach it.
+node and connects to it through the manager's SSH connection. Worker host keys
+are checked against your local known_hosts.
 
  Examples:
   swarmctl exec web                    # Opens shell in web container
@@ -29,6 +30,12 @@ node and uses SSH hop through the manager to reach it.
 	Run:  runExec,
 }
 
+// hopExecutor runs commands on worker nodes through the manager; it is
+// implemented by the SSH executor and, with --dry-run, the dry-run one
+type hopExecutor interface {
+	RunInteractiveOnHostContext(ctx context.Context, host, user, cmd string) error
+}
+
 func runExec(cmd *cobra.Command, args []string) {
 	red := color.New(color.FgRed).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
@@ -41,14 +48,14 @@ func runExec(cmd *cobra.Command, args []string) {
 	}
 
 	// Load config
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
 	}
 
 	// Create executor
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)
@@ -79,33 +86,32 @@ func runExec(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	// Ctrl+C ends the remote session instead of leaving it running
+	ctx, stop := interruptContext(cmd)
+	defer stop()
+
 	if needsHop {
 		// Container is on a worker node, need SSH hop
 		fmt.Printf("%s Container is on node %s (IP: %s)\n", yellow("⚡"), containerInfo.NodeName, containerInfo.NodeIP)
 
-		// Get SSH executor to check for agent forwarding
-		sshExec, ok := exec.(*executor.SSHExecutor)
+		// The worker is reached through the manager's SSH connection
+		hop, ok := exec.(hopExecutor)
 		if !ok {
 			fmt.Fprintf(os.Stderr, "%s SSH hop requires SSH executor\n", red("✗"))
 			os.Exit(1)
 		}
 
-		if !sshExec.HasAgentForwarding() {
-			fmt.Fprintf(os.Stderr, "%s SSH agent forwarding not available. Ensure ssh-agent is running and has your key loaded (ssh-add).\n", red("✗"))
-			os.Exit(1)
-		}
-
 		// Determine SSH user for the worker node
 		targetUser := cfg.SSH.User // fallback to manager user
 		if nodeConfig, ok := cfg.Nodes[containerInfo.NodeName]; ok && nodeConfig.User != "" {
 			targetUser = nodeConfig.User
 		}
 
-		fmt.Printf("%s SSH hop to %s@%s\n", cyan("→"), targetUser, containerInfo.NodeIP)
+		fmt.Printf("%s SSH hop to %s@%s via %s\n", cyan("→"), targetUser, containerInfo.NodeIP, cfg.SSH.Host)
 		fmt.Printf("%s Executing: %s\n\n", cyan("→"), strings.Join(command, " "))
 
 		// Run via SSH hop
-		if err := sshExec.RunInteractiveOnHost(containerInfo.NodeIP, targetUser, dockerExecCmd); err != nil {
+		if err := hop.RunInteractiveOnHostContext(ctx, containerInfo.NodeIP, targetUser, dockerExecCmd); err != nil {
 			fmt.Fprintf(os.Stderr, "\n%s Command failed: %v\n", red("✗"), err)
 			os.Exit(1)
 		}
@@ -113,7 +119,7 @@ func runExec(cmd *cobra.Command, args []string) {
 		// Container is on current node or compose mode, exec directly
 		fmt.Printf("%s Executing: %s\n\n", cyan("→"), strings.Join(command, " "))
 
-		if err := exec.RunInteractive(dockerExecCmd); err != nil {
+		if err := exec.RunInteractiveContext(ctx, dockerExecCmd); err != nil {
 			fmt.Fprintf(os.Stderr, "\n%s Command failed: %v\n", red("✗"), err)
 			os.Exit(1)
 		}
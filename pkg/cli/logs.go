@@ -5,9 +5,7 @@ import (
 	"os"
 
 	"github.com/fatih/color"
-	"github.com/marcelsud/swarmctl/internal/config"
 	"github.com/marcelsud/swarmctl/internal/deployment"
-	"github.com/marcelsud/swarmctl/internal/executor"
 	"github.com/spf13/cobra"
 )
 
@@ -37,14 +35,14 @@ func runLogs(cmd *cobra.Command, args []string) {
 	cyan := color.New(color.FgCyan).SprintFunc()
 
 	// Load config
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
 	}
 
 	// Create executor
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)
@@ -97,9 +95,13 @@ func runLogs(cmd *cobra.Command, args []string) {
 	// For follow mode, stream logs interactively
 	if logsFollow {
 		fmt.Printf("%s Streaming logs for %s (Ctrl+C to stop)...\n\n", cyan("→"), serviceName)
-		if err := mgr.StreamServiceLogs(serviceName, true, logsTail, os.Stdout, os.Stderr); err != nil {
-			// Ignore error on Ctrl+C
-			return
+		ctx, stop := interruptContext(cmd)
+		defer stop()
+
+		// Ctrl+C stops the remote command and is not an error
+		if err := mgr.StreamServiceLogs(ctx, serviceName, true, logsTail, os.Stdout, os.Stderr); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "%s Failed to stream logs: %v\n", red("✗"), err)
+			os.Exit(1)
 		}
 		return
 	}
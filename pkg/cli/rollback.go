@@ -7,7 +7,6 @@ import (
 	"github.com/fatih/color"
 	"github.com/marcelsud/swarmctl/internal/config"
 	"github.com/marcelsud/swarmctl/internal/deployment"
-	"github.com/marcelsud/swarmctl/internal/executor"
 	"github.com/spf13/cobra"
 )
 
@@ -40,14 +39,14 @@ func runRollback(cmd *cobra.Command, args []string) {
 	}
 
 	// Load config
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
 	}
 
 	// Create executor
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)
@@ -1,9 +1,17 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
+	"github.com/marcelsud/swarmctl/internal/config"
+	"github.com/marcelsud/swarmctl/internal/executor"
+	"github.com/marcelsud/swarmctl/internal/secrets"
+	"github.com/marcelsud/swarmctl/internal/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -11,6 +19,7 @@ var (
 	configFile  string
 	destination string
 	verbose     bool
+	dryRun      bool
 )
 
 var rootCmd = &cobra.Command{
@@ -22,21 +31,16 @@ managing your Swarm deployments via SSH.
 
 Multiple environments are supported via the -d flag:
   swarmctl deploy                  # Uses swarm.yaml
-  swarmctl deploy -d staging       # Uses swarm.staging.yaml
-  swarmctl deploy -d production    # Uses swarm.production.yaml`,
+  swarmctl deploy -d staging       # Uses swarm.yaml + swarm.staging.yaml
+  swarmctl deploy -d production    # Uses swarm.yaml + swarm.production.yaml`,
 	Version: "0.1.0",
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// If destination is set and config is default, use swarm.{destination}.yaml
-		if destination != "" && configFile == "swarm.yaml" {
-			configFile = fmt.Sprintf("swarm.%s.yaml", destination)
-		}
-	},
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "swarm.yaml", "config file path")
 	rootCmd.PersistentFlags().StringVarP(&destination, "destination", "d", "", "deployment destination (e.g., staging, production)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "print the commands that would change the target instead of running them")
 
 	// Add subcommands
 	rootCmd.AddCommand(setupCmd)
@@ -49,6 +53,64 @@ func init() {
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(docsCmd)
 	rootCmd.AddCommand(initLLMCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// loadConfig loads the config file, merging the destination file on top
+// of it when -d is set
+func loadConfig() (*config.Config, error) {
+	return config.LoadDestination(configFile, destination)
+}
+
+// newExecutor connects to the target. With --dry-run, read-only commands
+// still run but the ones that would change the target are printed as a
+// numbered plan when the executor is closed.
+func newExecutor(cfg *config.Config) (executor.Executor, error) {
+	exec, err := executor.New(cfg)
+	if err != nil || !dryRun {
+		return exec, err
+	}
+
+	dry := executor.NewDryRun(exec, os.Stdout)
+	dry.Redact(cfg.Registry.Password)
+	return dry, nil
+}
+
+// redactSecrets hides secret values from the --dry-run plan
+func redactSecrets(exec executor.Executor, list []secrets.Secret) {
+	if dry, ok := exec.(*executor.DryRunExecutor); ok {
+		for _, secret := range list {
+			dry.Redact(secret.Value)
+		}
+	}
+}
+
+// envFile returns the .env file with secret values and compose variables:
+// env_file from swarm.yaml, else .env.<destination> next to swarm.yaml if
+// it exists, else .env
+func envFile(cfg *config.Config) string {
+	return cfg.EnvFilePath(filepath.Dir(configFile), destination)
+}
+
+// secretSources returns where secret values are read from: the encrypted
+// secrets file for the current destination and envFile, which must exist
+// when set with env_file
+func secretSources(cfg *config.Config, envFile string) secrets.Sources {
+	dir := filepath.Dir(configFile)
+	return secrets.Sources{
+		EnvFile:        envFile,
+		RequireEnvFile: cfg.EnvFile != "",
+		EncryptedFile:  vault.FilePath(dir, destination),
+		Key: func() (*vault.Key, error) {
+			return vault.LoadKey(dir, destination)
+		},
+	}
+}
+
+// interruptContext returns a context that is cancelled on Ctrl+C or
+// SIGTERM, so the remote command is stopped instead of left running
+func interruptContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
 }
 
 func Execute() error {
@@ -1,17 +1,26 @@
 package cli
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/marcelsud/swarmctl/internal/config"
-	"github.com/marcelsud/swarmctl/internal/executor"
+	"github.com/marcelsud/swarmctl/internal/dotenv"
 	"github.com/marcelsud/swarmctl/internal/secrets"
+	"github.com/marcelsud/swarmctl/internal/vault"
 	"github.com/spf13/cobra"
 )
 
-var secretsEnvFile string
+var (
+	secretsEnvFile string
+	secretsUsage   bool
+	secretsYes     bool
+)
 
 var secretsCmd = &cobra.Command{
 	Use:   "secrets",
@@ -22,22 +31,81 @@ var secretsCmd = &cobra.Command{
 var secretsPushCmd = &cobra.Command{
 	Use:   "push",
 	Short: "Push secrets from .env to Swarm",
-	Long: `Push secrets defined in swarm.yaml from .env file or environment variables.
-Secrets are created with the format: {stack}_{secret_name}`,
+	Long: `Push secrets defined in swarm.yaml from the encrypted secrets file, the
+.env file or environment variables.
+Secrets are created with the format: {stack}_{secret_name}_{hash}, where the
+hash changes with the value. Services switch to new versions on deploy.`,
 	Run: runSecretsPush,
 }
 
+var secretsDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare local secrets with Swarm",
+	Long: `Compare the secrets defined in swarm.yaml with the versions on the Swarm.
+Shows secrets that are new, changed, missing from the .env file, or present
+on the Swarm but no longer listed in swarm.yaml. Values are compared by a
+salted fingerprint; secret values are never read back.`,
+	Run: runSecretsDiff,
+}
+
+var secretsEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the encrypted secrets file",
+	Long: `Decrypt .swarmctl/secrets.<destination>.enc into $EDITOR and encrypt it
+again on save. The file uses the .env format and can be committed.
+
+The master key comes from SWARMCTL_MASTER_KEY or from the key file
+.swarmctl/<destination>.key (falling back to .swarmctl/master.key). When
+neither exists, a new key file is created; keep it out of git.`,
+	Run: runSecretsEdit,
+}
+
+var secretsShowCmd = &cobra.Command{
+	Use:   "show NAME",
+	Short: "Print a value from the encrypted secrets file",
+	Args:  cobra.ExactArgs(1),
+	Run:   runSecretsShow,
+}
+
 var secretsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List existing secrets",
-	Run:   runSecretsList,
+	Long: `List the Docker secrets of the stack. With --usage, also show the
+services using each secret.`,
+	Run: runSecretsList,
+}
+
+var secretsRemoveCmd = &cobra.Command{
+	Use:   "remove NAME",
+	Short: "Remove every version of a secret",
+	Long: `Remove every version of a secret from the Swarm. Secrets used by a
+service can't be removed; remove them from the compose file and deploy first.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSecretsRemove,
+}
+
+var secretsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove secrets no longer listed in swarm.yaml",
+	Long: `Remove the stack's Docker secrets that are no longer listed in
+swarm.yaml and are not used by any service.`,
+	Run: runSecretsPrune,
 }
 
 func init() {
-	secretsPushCmd.Flags().StringVarP(&secretsEnvFile, "env-file", "e", ".env", "path to .env file")
+	secretsPushCmd.Flags().StringVarP(&secretsEnvFile, "env-file", "e", "", "path to .env file (default: env_file from swarm.yaml, .env.<destination> or .env)")
+	secretsDiffCmd.Flags().StringVarP(&secretsEnvFile, "env-file", "e", "", "path to .env file (default: env_file from swarm.yaml, .env.<destination> or .env)")
 
 	secretsCmd.AddCommand(secretsPushCmd)
+	secretsCmd.AddCommand(secretsDiffCmd)
+	secretsListCmd.Flags().BoolVar(&secretsUsage, "usage", false, "show the services using each secret")
+	secretsPruneCmd.Flags().BoolVarP(&secretsYes, "yes", "y", false, "remove without asking for confirmation")
+
 	secretsCmd.AddCommand(secretsListCmd)
+	secretsCmd.AddCommand(secretsRemoveCmd)
+	secretsCmd.AddCommand(secretsPruneCmd)
+	secretsCmd.AddCommand(secretsEditCmd)
+	secretsCmd.AddCommand(secretsShowCmd)
 }
 
 func runSecretsPush(cmd *cobra.Command, args []string) {
@@ -47,7 +115,7 @@ func runSecretsPush(cmd *cobra.Command, args []string) {
 	cyan := color.New(color.FgCyan).SprintFunc()
 
 	// Load config
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
@@ -58,20 +126,16 @@ func runSecretsPush(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	fmt.Printf("%s Loading secrets for: %v\n", cyan("→"), cfg.Secrets)
+	fmt.Printf("%s Loading secrets for: %s\n", cyan("→"), strings.Join(cfg.Secrets.Names(), ", "))
 
-	// Load secrets from .env file or environment
-	var secretList []secrets.Secret
-	if _, err := os.Stat(secretsEnvFile); err == nil {
-		secretList, err = secrets.LoadFromEnvFile(secretsEnvFile, cfg.Secrets)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
-			os.Exit(1)
-		}
-		fmt.Printf("  Loaded from %s\n", secretsEnvFile)
-	} else {
-		secretList = secrets.LoadFromEnv(cfg.Secrets)
-		fmt.Printf("  Loaded from environment variables\n")
+	// Load secrets from their providers
+	secretList, err := secrets.Load(secretsSources(cfg), cfg.Secrets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+	for _, secret := range secretList {
+		fmt.Printf("  %s loaded from %s\n", secret.Name, secret.Source)
 	}
 
 	if len(secretList) == 0 {
@@ -80,43 +144,66 @@ func runSecretsPush(cmd *cobra.Command, args []string) {
 	}
 
 	// Create executor
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)
 	}
 	defer exec.Close()
+	redactSecrets(exec, secretList)
 
-	mgr := secrets.NewManager(exec, cfg.Stack)
+	mgr := secrets.New(cfg, exec)
 
 	// Push secrets
 	fmt.Printf("%s Pushing %d secret(s)...\n", cyan("→"), len(secretList))
 
 	for _, secret := range secretList {
 		fmt.Printf("  %s %s...", cyan("→"), secret.Name)
-		if err := mgr.Create(secret.Name, secret.Value); err != nil {
+		versioned, created, err := mgr.Create(secret.Name, secret.Value)
+		if err != nil {
 			fmt.Printf(" %s (%v)\n", red("✗"), err)
 			continue
 		}
-		fmt.Printf(" %s\n", green("✓"))
+
+		status := "unchanged"
+		if created {
+			status = "created"
+		}
+		fmt.Printf(" %s %s (%s)\n", green("✓"), versioned, status)
 	}
 
 	fmt.Printf("\n%s Secrets pushed successfully\n", green("✓"))
+	fmt.Printf("  Run %s to roll services over to new versions\n", cyan("swarmctl deploy"))
 }
 
-func runSecretsList(cmd *cobra.Command, args []string) {
+func runSecretsDiff(cmd *cobra.Command, args []string) {
+	green := color.New(color.FgGreen).SprintFunc()
 	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
 
 	// Load config
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	if cfg.Mode == config.ModeCompose {
+		fmt.Fprintf(os.Stderr, "%s secrets diff is not supported in compose mode\n", red("✗"))
+		os.Exit(1)
+	}
+
+	// Load secrets from their providers
+	resolver := secrets.NewResolver(secretsSources(cfg))
+	secretList, err := resolver.Load(cfg.Secrets)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
 	}
 
 	// Create executor
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)
@@ -125,8 +212,85 @@ func runSecretsList(cmd *cobra.Command, args []string) {
 
 	mgr := secrets.NewManager(exec, cfg.Stack)
 
+	diffs, err := mgr.Diff(cfg.Secrets.Names(), secretList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to compare secrets: %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	if len(diffs) == 0 {
+		fmt.Printf("%s No secrets defined in swarm.yaml or found for stack %s\n", cyan("→"), cfg.Stack)
+		return
+	}
+
+	entries := make(map[string]config.SecretConfig, len(cfg.Secrets))
+	for _, entry := range cfg.Secrets {
+		entries[entry.Name] = entry
+	}
+
+	fmt.Printf("%s Secrets for stack %s:\n", cyan("→"), cfg.Stack)
+	pending := 0
+	for _, d := range diffs {
+		switch d.Status {
+		case secrets.StatusNew:
+			fmt.Printf("  %s %s (new)\n", green("+"), d.Name)
+			pending++
+		case secrets.StatusChanged:
+			fmt.Printf("  %s %s (changed)\n", yellow("~"), d.Name)
+			pending++
+		case secrets.StatusUnchanged:
+			fmt.Printf("  %s %s (unchanged)\n", " ", d.Name)
+		case secrets.StatusMissing:
+			fmt.Printf("  %s %s (no value in %s)\n", red("?"), d.Name, resolver.Source(entries[d.Name]))
+		case secrets.StatusUnlisted:
+			fmt.Printf("  %s %s (not listed in swarm.yaml: %s)\n", red("-"), d.Name, strings.Join(d.Versions, ", "))
+		}
+	}
+
+	if pending == 0 {
+		fmt.Printf("\n%s Swarm secrets are up to date\n", green("✓"))
+	} else {
+		fmt.Printf("\n%s %d secret(s) will be pushed on the next deploy or secrets push\n", cyan("→"), pending)
+	}
+}
+
+// secretsSources returns where secret values are read from. An explicit
+// --env-file replaces both the encrypted secrets file and the default
+// env file.
+func secretsSources(cfg *config.Config) secrets.Sources {
+	if secretsEnvFile != "" {
+		return secrets.Sources{EnvFile: secretsEnvFile, RequireEnvFile: true}
+	}
+	return secretSources(cfg, envFile(cfg))
+}
+
+func runSecretsList(cmd *cobra.Command, args []string) {
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	// Load config
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	if secretsUsage && cfg.Mode == config.ModeCompose {
+		fmt.Fprintf(os.Stderr, "%s secrets list --usage is not supported in compose mode\n", red("✗"))
+		os.Exit(1)
+	}
+
+	// Create executor
+	exec, err := newExecutor(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+	defer exec.Close()
+
 	// List secrets
-	secretsList, err := mgr.List()
+	secretsList, err := secrets.New(cfg, exec).List()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to list secrets: %v\n", red("✗"), err)
 		os.Exit(1)
@@ -137,8 +301,306 @@ func runSecretsList(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if !secretsUsage {
+		fmt.Printf("%s Secrets for stack %s:\n", cyan("→"), cfg.Stack)
+		for _, secret := range secretsList {
+			fmt.Printf("  - %s\n", secret)
+		}
+		return
+	}
+
+	usage, err := secrets.NewManager(exec, cfg.Stack).Usage()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to inspect services: %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
 	fmt.Printf("%s Secrets for stack %s:\n", cyan("→"), cfg.Stack)
 	for _, secret := range secretsList {
+		services := usage.Services(secret)
+		if len(services) == 0 {
+			fmt.Printf("  - %s %s\n", secret, yellow("(unused)"))
+			continue
+		}
+		fmt.Printf("  - %s → %s\n", secret, strings.Join(services, ", "))
+	}
+}
+
+func runSecretsRemove(cmd *cobra.Command, args []string) {
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	name := args[0]
+
+	// Load config
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	if cfg.Mode == config.ModeCompose {
+		fmt.Fprintf(os.Stderr, "%s secrets remove is not supported in compose mode\n", red("✗"))
+		os.Exit(1)
+	}
+
+	// Create executor
+	exec, err := newExecutor(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+	defer exec.Close()
+
+	mgr := secrets.NewManager(exec, cfg.Stack)
+
+	versions, err := mgr.Versions(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to list secrets: %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+	if len(versions) == 0 {
+		fmt.Fprintf(os.Stderr, "%s Secret %s not found for stack %s\n", red("✗"), name, cfg.Stack)
+		os.Exit(1)
+	}
+
+	usage, err := mgr.Usage()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to inspect services: %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+	if services := usage.Services(versions...); len(services) > 0 {
+		fmt.Fprintf(os.Stderr, "%s Secret %s is used by %s\n", red("✗"), name, strings.Join(services, ", "))
+		fmt.Fprintf(os.Stderr, "  Remove it from the compose file and deploy first\n")
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s Removing %d version(s) of %s...\n", cyan("→"), len(versions), name)
+	if err := mgr.Remove(versions); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s Secret %s removed\n", green("✓"), name)
+	listed := slices.ContainsFunc(cfg.Secrets.Names(), func(n string) bool { return strings.EqualFold(n, name) })
+	if listed {
+		fmt.Printf("%s %s is still listed in swarm.yaml; the next deploy will push it again\n", yellow("!"), name)
+	}
+}
+
+func runSecretsPrune(cmd *cobra.Command, args []string) {
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	// Load config
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	if cfg.Mode == config.ModeCompose {
+		fmt.Fprintf(os.Stderr, "%s secrets prune is not supported in compose mode\n", red("✗"))
+		os.Exit(1)
+	}
+
+	// Create executor
+	exec, err := newExecutor(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+	defer exec.Close()
+
+	mgr := secrets.NewManager(exec, cfg.Stack)
+
+	orphans, err := mgr.Orphans(cfg.Secrets.Names())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to find unused secrets: %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	if len(orphans) == 0 {
+		fmt.Printf("%s No unused secrets for stack %s\n", green("✓"), cfg.Stack)
+		return
+	}
+
+	fmt.Printf("%s Secrets not listed in swarm.yaml and not used by any service:\n", cyan("→"))
+	for _, secret := range orphans {
 		fmt.Printf("  - %s\n", secret)
 	}
+
+	if !secretsYes {
+		fmt.Printf("Remove %d secret(s)? (yes/no) ", len(orphans))
+
+		var response string
+		fmt.Scanln(&response)
+		if strings.ToLower(strings.TrimSpace(response)) != "yes" {
+			fmt.Printf("%s Aborted\n", yellow("!"))
+			return
+		}
+	}
+
+	removed := 0
+	for _, secret := range orphans {
+		fmt.Printf("  %s %s...", cyan("→"), secret)
+		if err := mgr.Remove([]string{secret}); err != nil {
+			fmt.Printf(" %s (%v)\n", red("✗"), err)
+			continue
+		}
+		fmt.Printf(" %s\n", green("✓"))
+		removed++
+	}
+
+	fmt.Printf("\n%s Removed %d of %d secret(s)\n", green("✓"), removed, len(orphans))
+}
+
+func runSecretsEdit(cmd *cobra.Command, args []string) {
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	dir := filepath.Dir(configFile)
+	path := vault.FilePath(dir, destination)
+
+	var key *vault.Key
+	var plaintext []byte
+	if _, err := os.Stat(path); err == nil {
+		key, err = vault.LoadKey(dir, destination)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+			os.Exit(1)
+		}
+		plaintext, err = vault.ReadFile(path, key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+			os.Exit(1)
+		}
+	} else {
+		key, err = newSecretsFileKey(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+			os.Exit(1)
+		}
+		plaintext = secretsFileTemplate(cfg)
+	}
+
+	edited := plaintext
+	for {
+		edited, err = vault.Edit(edited)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+			os.Exit(1)
+		}
+
+		_, err = dotenv.Parse(bytes.NewReader(edited))
+		if err == nil {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		fmt.Printf("Edit again? Answering no discards your changes (yes/no) ")
+
+		var response string
+		fmt.Scanln(&response)
+		if strings.ToLower(strings.TrimSpace(response)) != "yes" {
+			fmt.Printf("%s Changes discarded\n", yellow("!"))
+			return
+		}
+	}
+
+	if bytes.Equal(edited, plaintext) {
+		if _, err := os.Stat(path); err == nil {
+			fmt.Printf("%s No changes\n", cyan("→"))
+			return
+		}
+	}
+
+	if err := vault.WriteFile(path, key, edited); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s Saved %s\n", green("✓"), path)
+}
+
+func runSecretsShow(cmd *cobra.Command, args []string) {
+	red := color.New(color.FgRed).SprintFunc()
+
+	dir := filepath.Dir(configFile)
+	path := vault.FilePath(dir, destination)
+
+	key, err := vault.LoadKey(dir, destination)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	env, err := vault.Read(path, key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
+		os.Exit(1)
+	}
+
+	value, ok := env[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s %s not found in %s\n", red("✗"), args[0], path)
+		os.Exit(1)
+	}
+	fmt.Print(value)
+	if !strings.HasSuffix(value, "\n") {
+		fmt.Println()
+	}
+}
+
+// newSecretsFileKey returns the master key for a new encrypted secrets
+// file, creating a key file when none is available
+func newSecretsFileKey(dir string) (*vault.Key, error) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	if os.Getenv(vault.KeyEnv) != "" {
+		return vault.LoadKey(dir, destination)
+	}
+	if _, err := os.Stat(vault.KeyPath(dir, destination)); err == nil {
+		return vault.LoadKey(dir, destination)
+	}
+
+	key, err := vault.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	name := "master.key"
+	if destination != "" {
+		name = destination + ".key"
+	}
+	keyPath := filepath.Join(dir, vault.Dir, name)
+	if err := vault.WriteKey(keyPath, key); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("%s Created master key %s\n", yellow("!"), keyPath)
+	fmt.Printf("  Keep it out of git and back it up; without it the secrets can't be decrypted.\n")
+	fmt.Printf("  In CI, set %s to its content.\n", vault.KeyEnv)
+	return key, nil
+}
+
+// secretsFileTemplate returns the initial content of a new encrypted
+// secrets file, listing the secrets defined in swarm.yaml
+func secretsFileTemplate(cfg *config.Config) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Secrets for stack %s, in .env format\n", cfg.Stack)
+	for _, name := range cfg.Secrets.Names() {
+		fmt.Fprintf(&b, "%s=\n", name)
+	}
+	return []byte(b.String())
 }
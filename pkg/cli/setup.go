@@ -39,7 +39,7 @@ func runSetup(cmd *cobra.Command, args []string) {
 
 	// Load config
 	fmt.Printf("%s Loading configuration...\n", cyan("→"))
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s %v\n", red("✗"), err)
 		os.Exit(1)
@@ -61,7 +61,7 @@ func runSetup(cmd *cobra.Command, args []string) {
 	fmt.Printf("  Mode:  %s\n", bold(modeStr))
 
 	// Create executor
-	exec, err := executor.New(cfg)
+	exec, err := newExecutor(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to connect: %v\n", red("✗"), err)
 		os.Exit(1)